@@ -0,0 +1,80 @@
+package stash
+
+import (
+	"context"
+	"fmt"
+)
+
+// Activity actions.
+const (
+	ActivityOpened     = "OPENED"
+	ActivityCommented  = "COMMENTED"
+	ActivityApproved   = "APPROVED"
+	ActivityUnapproved = "UNAPPROVED"
+	ActivityReviewed   = "REVIEWED"
+	ActivityRescoped   = "RESCOPED"
+	ActivityUpdated    = "UPDATED"
+	ActivityMerged     = "MERGED"
+	ActivityDeclined   = "DECLINED"
+	ActivityReopened   = "REOPENED"
+)
+
+type (
+	// Activity is an event in the history of a pull request.  Which of the
+	// optional fields are set depends on Action.
+	Activity struct {
+		ID          int    `json:"id"`
+		CreatedDate int64  `json:"createdDate"`
+		User        User   `json:"user"`
+		Action      string `json:"action"`
+
+		// COMMENTED: CommentAction is ADDED, EDITED, REPLIED or DELETED.
+		CommentAction string   `json:"commentAction,omitempty"`
+		Comment       *Comment `json:"comment,omitempty"`
+		CommentAnchor *Anchor  `json:"commentAnchor,omitempty"`
+
+		// RESCOPED: the source or target branch moved.
+		FromHash         string           `json:"fromHash,omitempty"`
+		PreviousFromHash string           `json:"previousFromHash,omitempty"`
+		ToHash           string           `json:"toHash,omitempty"`
+		PreviousToHash   string           `json:"previousToHash,omitempty"`
+		Added            *ActivityCommits `json:"added,omitempty"`
+		Removed          *ActivityCommits `json:"removed,omitempty"`
+
+		// MERGED: the merge commit.
+		Commit *Commit `json:"commit,omitempty"`
+
+		// UPDATED: reviewers changes.
+		AddedReviewers   []User `json:"addedReviewers,omitempty"`
+		RemovedReviewers []User `json:"removedReviewers,omitempty"`
+	}
+
+	// ActivityCommits are the commits added to or removed from a pull request by
+	// a RESCOPED activity.  Total may exceed len(Commits).
+	ActivityCommits struct {
+		Commits []Commit `json:"commits"`
+		Total   int      `json:"total"`
+	}
+)
+
+// GetPullRequestActivities returns the history of a pull request, newest first.
+func (client Client) GetPullRequestActivities(projectKey, repositorySlug string, pullRequestID int) ([]Activity, error) {
+	return client.GetPullRequestActivitiesContext(context.Background(), projectKey, repositorySlug, pullRequestID)
+}
+
+// GetPullRequestActivitiesContext is like GetPullRequestActivities but uses ctx for every page it requests.
+func (client Client) GetPullRequestActivitiesContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) ([]Activity, error) {
+	var activities []Activity
+	it := client.Paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/activities", projectKey, repositorySlug, pullRequestID), nil, PageOptions{})
+	for it.Next() {
+		var activity Activity
+		if err := it.Decode(&activity); err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
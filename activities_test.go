@@ -0,0 +1,90 @@
+package stash
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGetPullRequestActivities(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/activities" {
+			t.Errorf("Want activities path but got %s\n", r.URL.Path)
+		}
+		fmt.Fprint(w, `{
+    "isLastPage": true,
+    "values": [
+        {
+            "id": 5,
+            "createdDate": 1500000004000,
+            "user": {"name": "bob"},
+            "action": "MERGED",
+            "commit": {"id": "e5f6", "displayId": "e5f6"}
+        },
+        {
+            "id": 4,
+            "createdDate": 1500000003000,
+            "user": {"name": "carol"},
+            "action": "RESCOPED",
+            "fromHash": "c3d4",
+            "previousFromHash": "a1b2",
+            "added": {"commits": [{"id": "c3d4"}], "total": 1},
+            "removed": {"commits": [], "total": 0}
+        },
+        {
+            "id": 3,
+            "createdDate": 1500000002000,
+            "user": {"name": "bob"},
+            "action": "COMMENTED",
+            "commentAction": "ADDED",
+            "comment": {"id": 1, "text": "typo"},
+            "commentAnchor": {"path": "README.md", "line": 1, "lineType": "ADDED", "fileType": "TO"}
+        },
+        {
+            "id": 2,
+            "createdDate": 1500000001000,
+            "user": {"name": "alice"},
+            "action": "UPDATED",
+            "addedReviewers": [{"name": "bob"}],
+            "removedReviewers": []
+        },
+        {
+            "id": 1,
+            "createdDate": 1500000000000,
+            "user": {"name": "alice"},
+            "action": "OPENED"
+        }
+    ]
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	activities, err := stashClient.GetPullRequestActivities("PROJ", "slug", 7)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(activities) != 5 {
+		t.Fatalf("Want 5 activities but got %d\n", len(activities))
+	}
+
+	merged, rescoped, commented, updated, opened := activities[0], activities[1], activities[2], activities[3], activities[4]
+	if merged.Action != ActivityMerged || merged.Commit == nil || merged.Commit.ID != "e5f6" {
+		t.Fatalf("Want a merge with commit e5f6 but got %+v\n", merged)
+	}
+	if rescoped.Action != ActivityRescoped || rescoped.Added.Total != 1 || rescoped.PreviousFromHash != "a1b2" {
+		t.Fatalf("Want a rescope adding c3d4 but got %+v\n", rescoped)
+	}
+	if commented.Comment == nil || commented.Comment.Text != "typo" || commented.CommentAnchor.Line != 1 {
+		t.Fatalf("Want the typo comment on line 1 but got %+v\n", commented)
+	}
+	if len(updated.AddedReviewers) != 1 || updated.AddedReviewers[0].Name != "bob" {
+		t.Fatalf("Want bob added as a reviewer but got %+v\n", updated)
+	}
+	if opened.Action != ActivityOpened || opened.User.Name != "alice" || opened.CreatedDate != 1500000000000 {
+		t.Fatalf("Want alice opening the pull request but got %+v\n", opened)
+	}
+}
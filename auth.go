@@ -0,0 +1,112 @@
+package stash
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// Authenticator adds credentials to every request a client sends.  If it
+	// also has an Invalidate method, as RefreshingToken does, clients call it
+	// when the server answers 401 Unauthorized.
+	Authenticator interface {
+		Authenticate(req *http.Request) error
+	}
+
+	// invalidator is implemented by Authenticators that cache credentials.
+	invalidator interface {
+		Invalidate()
+	}
+
+	// BasicAuth authenticates with a user name and password.  When both are empty
+	// requests are sent anonymously, which works for public repositories.
+	BasicAuth struct {
+		UserName string
+		Password string
+	}
+
+	// BearerToken authenticates with a Bitbucket Server HTTP access token.
+	BearerToken string
+
+	// TokenFunc fetches a fresh access token and the time it expires.  A zero
+	// expiry means the token does not expire.
+	TokenFunc func(ctx context.Context) (token string, expiry time.Time, err error)
+
+	// RefreshingToken authenticates with a bearer token obtained from a TokenFunc,
+	// fetching a new one shortly before the current token expires.  It is safe for
+	// concurrent use.
+	RefreshingToken struct {
+		fetch TokenFunc
+		skew  time.Duration
+
+		mu     sync.Mutex
+		token  string
+		expiry time.Time
+	}
+)
+
+// Authenticate sets the basic authorization header unless no credentials were given.
+func (a BasicAuth) Authenticate(req *http.Request) error {
+	if a.UserName == "" && a.Password == "" {
+		return nil
+	}
+	req.SetBasicAuth(a.UserName, a.Password)
+	return nil
+}
+
+// Authenticate sets the bearer authorization header.
+func (t BearerToken) Authenticate(req *http.Request) error {
+	if t == "" {
+		return errors.New("stash: empty bearer token")
+	}
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// NewRefreshingToken returns a RefreshingToken that calls fetch for a new token
+// once the current one is within skew of its expiry.
+func NewRefreshingToken(fetch TokenFunc, skew time.Duration) (*RefreshingToken, error) {
+	if fetch == nil {
+		return nil, errors.New("stash: nil TokenFunc")
+	}
+	return &RefreshingToken{fetch: fetch, skew: skew}, nil
+}
+
+// Authenticate sets the bearer authorization header, refreshing the token first
+// if needed.  The refresh uses the request's context.
+func (r *RefreshingToken) Authenticate(req *http.Request) error {
+	token, err := r.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	return BearerToken(token).Authenticate(req)
+}
+
+// Token returns the cached token, fetching a new one if there is none yet or it
+// is about to expire.
+func (r *RefreshingToken) Token(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.token != "" && (r.expiry.IsZero() || time.Now().Add(r.skew).Before(r.expiry)) {
+		return r.token, nil
+	}
+
+	token, expiry, err := r.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	r.token, r.expiry = token, expiry
+	return token, nil
+}
+
+// Invalidate drops the cached token so the next request fetches a new one.
+// Clients call it when the server answers 401 Unauthorized.
+func (r *RefreshingToken) Invalidate() {
+	r.mu.Lock()
+	r.token = ""
+	r.mu.Unlock()
+}
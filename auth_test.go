@@ -0,0 +1,171 @@
+package stash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBearerToken(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			t.Errorf("Want Bearer s3cr3t but found %s\n", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient, err := NewClientWithOptions(url, WithBearerToken("s3cr3t"))
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if err := stashClient.DeleteBranch("PROJ", "slug", "feature/a"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
+
+func TestAnonymousBasicAuth(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Want no Authorization header but found %s\n", auth)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("", "", url)
+	if err := stashClient.DeleteBranch("PROJ", "slug", "feature/a"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
+
+func TestRefreshingToken(t *testing.T) {
+	fetches := 0
+	now := time.Now()
+	source, err := NewRefreshingToken(func(ctx context.Context) (string, time.Time, error) {
+		fetches++
+		if fetches == 1 {
+			// already inside the skew window, so the next request refreshes
+			return "t1", now.Add(time.Second), nil
+		}
+		return fmt.Sprintf("t%d", fetches), now.Add(time.Hour), nil
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	var seen []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient, err := NewClientWithOptions(url, WithAuthenticator(source))
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := stashClient.DeleteBranch("PROJ", "slug", "feature/a"); err != nil {
+			t.Fatalf("Not expecting error: %v\n", err)
+		}
+	}
+
+	want := []string{"Bearer t1", "Bearer t2", "Bearer t2"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Fatalf("Want %v but got %v\n", want, seen)
+	}
+	if fetches != 2 {
+		t.Fatalf("Want 2 fetches but got %d\n", fetches)
+	}
+}
+
+func TestRefreshingTokenInvalidatedOnUnauthorized(t *testing.T) {
+	fetches := 0
+	source, err := NewRefreshingToken(func(ctx context.Context) (string, time.Time, error) {
+		fetches++
+		return fmt.Sprintf("t%d", fetches), time.Time{}, nil
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient, err := NewClientWithOptions(url, WithAuthenticator(source))
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if err := stashClient.DeleteBranch("PROJ", "slug", "feature/a"); err == nil {
+		t.Fatalf("Want unauthorized error but got none\n")
+	}
+	if err := stashClient.DeleteBranch("PROJ", "slug", "feature/a"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if fetches != 2 {
+		t.Fatalf("Want 2 fetches but got %d\n", fetches)
+	}
+}
+
+// tracingToken wraps a RefreshingToken the way callers add their own headers.
+type tracingToken struct {
+	*RefreshingToken
+}
+
+func (t tracingToken) Authenticate(req *http.Request) error {
+	req.Header.Set("X-Trace", "1")
+	return t.RefreshingToken.Authenticate(req)
+}
+
+func TestWrappedRefreshingTokenInvalidatedOnUnauthorized(t *testing.T) {
+	fetches := 0
+	source, err := NewRefreshingToken(func(ctx context.Context) (string, time.Time, error) {
+		fetches++
+		return fmt.Sprintf("t%d", fetches), time.Time{}, nil
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient, err := NewClientWithOptions(url, WithAuthenticator(tracingToken{source}))
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if err := stashClient.DeleteBranch("PROJ", "slug", "feature/a"); err == nil {
+		t.Fatalf("Want unauthorized error but got none\n")
+	}
+	if err := stashClient.DeleteBranch("PROJ", "slug", "feature/a"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
+
+func TestNewRefreshingTokenNilFetch(t *testing.T) {
+	if _, err := NewRefreshingToken(nil, time.Minute); err == nil {
+		t.Fatalf("Want error for a nil TokenFunc but got none\n")
+	}
+}
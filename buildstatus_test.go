@@ -0,0 +1,78 @@
+package stash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSetBuildStatus(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Want POST but found %s\n", r.Method)
+		}
+		if r.URL.Path != "/rest/build-status/1.0/commits/a1b2c3" {
+			t.Errorf("Want build status path but got %s\n", r.URL.Path)
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Not expecting error: %v\n", err)
+		}
+		if body["state"] != "FAILED" || body["key"] != "widget-ci" || body["url"] != "https://ci/job/1" || body["name"] != "widget-ci #1" {
+			t.Errorf("Want failed widget-ci #1 but got %v\n", body)
+		}
+		if _, ok := body["dateAdded"]; ok {
+			t.Errorf("Want no dateAdded but got %v\n", body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	err := stashClient.SetBuildStatus("a1b2c3", BuildStatus{State: BuildFailed, Key: "widget-ci", Name: "widget-ci #1", URL: "https://ci/job/1"})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
+
+func TestGetBuildStatuses(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/build-status/1.0/commits/a1b2c3":
+			fmt.Fprint(w, `{
+    "isLastPage": true,
+    "values": [
+        {"state": "SUCCESSFUL", "key": "widget-ci", "name": "widget-ci #2", "url": "https://ci/job/2", "dateAdded": 1500000001000},
+        {"state": "INPROGRESS", "key": "widget-lint", "url": "https://ci/lint/7", "description": "Linting", "dateAdded": 1500000000000}
+    ]
+}`)
+		case "/rest/build-status/1.0/commits/stats/a1b2c3":
+			fmt.Fprint(w, `{"successful": 1, "inProgress": 1, "failed": 0}`)
+		default:
+			t.Errorf("Not expecting %s\n", r.URL.Path)
+		}
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	statuses, err := stashClient.GetBuildStatuses("a1b2c3")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(statuses) != 2 || statuses[0].State != BuildSuccessful || statuses[1].Description != "Linting" || statuses[0].DateAdded != 1500000001000 {
+		t.Fatalf("Want a successful and an in progress build but got %+v\n", statuses)
+	}
+
+	stats, err := stashClient.GetBuildStats("a1b2c3")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if stats != (BuildStats{Successful: 1, InProgress: 1}) {
+		t.Fatalf("Want 1 successful and 1 in progress but got %+v\n", stats)
+	}
+}
@@ -0,0 +1,51 @@
+package stash
+
+// Values of Change.Type.
+const (
+	ChangeAdd     = "ADD"
+	ChangeCopy    = "COPY"
+	ChangeDelete  = "DELETE"
+	ChangeModify  = "MODIFY"
+	ChangeMove    = "MOVE"
+	ChangeUnknown = "UNKNOWN"
+)
+
+// Values of Change.NodeType.
+const (
+	NodeFile      = "FILE"
+	NodeDirectory = "DIRECTORY"
+	NodeSubmodule = "SUBMODULE"
+)
+
+type (
+	// Change is a file added, removed or modified between two revisions.
+	Change struct {
+		Type     string `json:"type"`
+		NodeType string `json:"nodeType"`
+		// Path is the destination path; SrcPath is only set for moves and copies.
+		Path    Path  `json:"path"`
+		SrcPath *Path `json:"srcPath,omitempty"`
+		// ContentID and FromContentID are the git blob IDs of the file after
+		// and before the change.
+		ContentID        string `json:"contentId"`
+		FromContentID    string `json:"fromContentId"`
+		Executable       bool   `json:"executable"`
+		SrcExecutable    bool   `json:"srcExecutable"`
+		PercentUnchanged int    `json:"percentUnchanged"`
+	}
+
+	// Path is a file path as Stash splits it.
+	Path struct {
+		Components []string `json:"components"`
+		Parent     string   `json:"parent"`
+		Name       string   `json:"name"`
+		Extension  string   `json:"extension,omitempty"`
+		ToString   string   `json:"toString"`
+	}
+)
+
+// ModeChanged reports whether the change flips the executable bit of a file
+// that existed before and after it.
+func (change Change) ModeChanged() bool {
+	return change.Type != ChangeAdd && change.Type != ChangeDelete && change.Executable != change.SrcExecutable
+}
@@ -0,0 +1,55 @@
+package stash
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGetPullRequestChanges(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/changes" {
+			t.Errorf("Want changes path but got %s\n", r.URL.Path)
+		}
+		if r.URL.Query().Get("start") == "2" {
+			fmt.Fprint(w, `{"isLastPage": true, "values": [
+				{"type": "MODIFY", "nodeType": "FILE", "path": {"toString": "bin/run.sh"}, "executable": true, "srcExecutable": false}
+			]}`)
+			return
+		}
+		fmt.Fprint(w, `{"isLastPage": false, "nextPageStart": 2, "values": [
+			{"type": "ADD", "nodeType": "FILE", "contentId": "a1b2", "fromContentId": "0000", "path": {"components": ["docs", "usage.md"], "parent": "docs", "name": "usage.md", "extension": "md", "toString": "docs/usage.md"}},
+			{"type": "MOVE", "nodeType": "FILE", "path": {"toString": "NEWS.md"}, "srcPath": {"toString": "CHANGES.md"}, "percentUnchanged": 100}
+		]}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	changes, err := stashClient.GetPullRequestChanges("PROJ", "slug", 7)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("Want 3 changes but got %d\n", len(changes))
+	}
+	if added := changes[0]; added.Type != ChangeAdd || added.Path.Extension != "md" || added.ContentID != "a1b2" {
+		t.Fatalf("Want docs/usage.md added but got %+v\n", added)
+	}
+	if moved := changes[1]; moved.Type != ChangeMove || moved.SrcPath == nil || moved.SrcPath.ToString != "CHANGES.md" {
+		t.Fatalf("Want CHANGES.md moved to NEWS.md but got %+v\n", moved)
+	}
+	if !changes[2].ModeChanged() || changes[0].ModeChanged() {
+		t.Fatalf("Want only bin/run.sh to change mode\n")
+	}
+
+	paths, err := stashClient.GetPullRequestChangedPaths("PROJ", "slug", 7)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if fmt.Sprint(paths) != "[docs/usage.md NEWS.md bin/run.sh]" {
+		t.Fatalf("Want the three paths but got %v\n", paths)
+	}
+}
@@ -0,0 +1,184 @@
+package stash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Values of Anchor.LineType.
+const (
+	LineAdded   = "ADDED"
+	LineRemoved = "REMOVED"
+	LineContext = "CONTEXT"
+)
+
+// Values of Anchor.FileType: the line number refers to the source (FROM) or the
+// destination (TO) version of the file.
+const (
+	FileFrom = "FROM"
+	FileTo   = "TO"
+)
+
+// Values of Anchor.DiffType.
+const (
+	DiffEffective = "EFFECTIVE"
+	DiffRange     = "RANGE"
+	DiffCommit    = "COMMIT"
+)
+
+type commentUpdate struct {
+	Version int    `json:"version"`
+	Text    string `json:"text"`
+}
+
+// ReplyToComment answers the comment parentID.
+func (client Client) ReplyToComment(projectKey, repositorySlug, pullRequest string, parentID int, text string) (Comment, error) {
+	return client.ReplyToCommentContext(context.Background(), projectKey, repositorySlug, pullRequest, parentID, text)
+}
+
+// ReplyToCommentContext is like ReplyToComment but uses ctx for the request.
+func (client Client) ReplyToCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest string, parentID int, text string) (Comment, error) {
+	return client.postComment(ctx, projectKey, repositorySlug, pullRequest, CommentResource{Text: text, Parent: &CommentParent{ID: parentID}})
+}
+
+// UpdateComment replaces the text of a comment.  version must be the current
+// version of the comment, see IsStaleVersion.
+func (client Client) UpdateComment(projectKey, repositorySlug, pullRequest string, commentID, version int, text string) (Comment, error) {
+	return client.UpdateCommentContext(context.Background(), projectKey, repositorySlug, pullRequest, commentID, version, text)
+}
+
+// UpdateCommentContext is like UpdateComment but uses ctx for the request.
+func (client Client) UpdateCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest string, commentID, version int, text string) (Comment, error) {
+	reqBody, err := json.Marshal(commentUpdate{Version: version, Text: text})
+	if err != nil {
+		return Comment{}, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"PUT",
+		fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s/comments/%d", client.baseURL.String(), projectKey, repositorySlug, pullRequest, commentID),
+		bytes.NewBuffer(reqBody),
+	)
+	if err != nil {
+		return Comment{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-type", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "The comment was not updated due to a validation error."
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusForbidden:
+			reason = "Only the author of a comment can update it."
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusConflict:
+			reason = "The comment has been changed since it was read."
+		}
+		return Comment{}, newAPIError(req, responseCode, data, reason)
+	}
+
+	var comment Comment
+	err = json.Unmarshal(data, &comment)
+	return comment, err
+}
+
+// DeleteComment deletes a comment at version.  Comments with replies cannot be
+// deleted.
+func (client Client) DeleteComment(projectKey, repositorySlug, pullRequest string, commentID, version int) error {
+	return client.DeleteCommentContext(context.Background(), projectKey, repositorySlug, pullRequest, commentID, version)
+}
+
+// DeleteCommentContext is like DeleteComment but uses ctx for the request.
+func (client Client) DeleteCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest string, commentID, version int) error {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"DELETE",
+		fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s/comments/%d?version=%d", client.baseURL.String(), projectKey, repositorySlug, pullRequest, commentID, version),
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+
+	if responseCode != http.StatusNoContent {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusForbidden:
+			reason = "Only the author of a comment can delete it."
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusConflict:
+			reason = "The comment has replies or has been changed since it was read."
+		}
+		return newAPIError(req, responseCode, data, reason)
+	}
+	return nil
+}
+
+// GetAllComments returns every comment of a pull request, general or anchored,
+// oldest first.  Replies are nested in the comment they answer.
+func (client Client) GetAllComments(projectKey, repositorySlug, pullRequest string) ([]Comment, error) {
+	return client.GetAllCommentsContext(context.Background(), projectKey, repositorySlug, pullRequest)
+}
+
+// GetAllCommentsContext is like GetAllComments but uses ctx for every page it requests.
+func (client Client) GetAllCommentsContext(ctx context.Context, projectKey, repositorySlug, pullRequest string) ([]Comment, error) {
+	var comments []Comment
+	it := client.Paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s/activities", projectKey, repositorySlug, pullRequest), nil, PageOptions{})
+	for it.Next() {
+		var activity Activity
+		if err := it.Decode(&activity); err != nil {
+			return nil, err
+		}
+		if activity.Action == ActivityCommented && activity.CommentAction == "ADDED" && activity.Comment != nil {
+			comments = append(comments, *activity.Comment)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	// Replies have an activity of their own but already appear in their thread.
+	replies := make(map[int]bool)
+	var mark func([]Comment)
+	mark = func(thread []Comment) {
+		for _, reply := range thread {
+			replies[reply.ID] = true
+			mark(reply.Comments)
+		}
+	}
+	for _, comment := range comments {
+		mark(comment.Comments)
+	}
+
+	// The activity stream is newest first.
+	var roots []Comment
+	for i := len(comments) - 1; i >= 0; i-- {
+		if !replies[comments[i].ID] {
+			roots = append(roots, comments[i])
+		}
+	}
+	return roots, nil
+}
@@ -0,0 +1,209 @@
+package stash
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCreateAnchoredComment(t *testing.T) {
+	var tests = []struct {
+		anchor Anchor
+		want   string
+	}{
+		{
+			anchor: Anchor{Path: "src/main.go", Line: 42, LineType: LineAdded, FileType: FileTo},
+			want:   `{"text":"unused variable","anchor":{"path":"src/main.go","line":42,"lineType":"ADDED","fileType":"TO"}}`,
+		},
+		{
+			anchor: Anchor{Path: "src/main.go"},
+			want:   `{"text":"unused variable","anchor":{"path":"src/main.go"}}`,
+		},
+	}
+
+	for testNumber, test := range tests {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/comments" {
+				t.Errorf("Test %d: want comments path but got %s\n", testNumber, r.URL.Path)
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			if string(body) != test.want {
+				t.Errorf("Test %d: want %s but got %s\n", testNumber, test.want, body)
+			}
+			var resource CommentResource
+			if err := json.Unmarshal(body, &resource); err != nil {
+				t.Errorf("Test %d: not expecting error: %v\n", testNumber, err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Comment{ID: 1, Text: resource.Text, Anchor: *resource.Anchor})
+		}))
+		defer testServer.Close()
+
+		url, _ := url.Parse(testServer.URL)
+		stashClient := NewClient("u", "p", url)
+		comment, err := stashClient.CreateAnchoredComment("PROJ", "slug", "7", "unused variable", test.anchor)
+		if err != nil {
+			t.Fatalf("Test %d: not expecting error: %v\n", testNumber, err)
+		}
+		if comment.Anchor != test.anchor {
+			t.Fatalf("Test %d: want %+v but got %+v\n", testNumber, test.anchor, comment.Anchor)
+		}
+	}
+}
+
+func TestGetCommentsAnchors(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path := r.URL.Query().Get("path"); path != "src/main.go" {
+			t.Errorf("Want path src/main.go but got %s\n", path)
+		}
+		fmt.Fprint(w, `{
+    "isLastPage": true,
+    "values": [
+        {
+            "id": 1,
+            "text": "unused variable",
+            "anchor": {
+                "path": "src/main.go",
+                "line": 42,
+                "lineType": "REMOVED",
+                "fileType": "FROM",
+                "diffType": "EFFECTIVE",
+                "fromHash": "a1b2c3",
+                "toHash": "d4e5f6"
+            }
+        }
+    ]
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	comments, err := stashClient.GetComments("PROJ", "slug", "7", "src/main.go")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	want := Anchor{Path: "src/main.go", Line: 42, LineType: LineRemoved, FileType: FileFrom, DiffType: DiffEffective, FromHash: "a1b2c3", ToHash: "d4e5f6"}
+	if len(comments) != 1 || comments[0].Anchor != want {
+		t.Fatalf("Want %+v but got %+v\n", want, comments)
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			t.Errorf("Want PUT but found %s\n", r.Method)
+		}
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/comments/3" {
+			t.Errorf("Want comment path but got %s\n", r.URL.Path)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if want := `{"version":0,"text":"build passing"}`; string(body) != want {
+			t.Errorf("Want %s but got %s\n", want, body)
+		}
+		fmt.Fprint(w, `{"id": 3, "version": 1, "text": "build passing"}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	comment, err := stashClient.UpdateComment("PROJ", "slug", "7", 3, 0, "build passing")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if comment.Version != 1 {
+		t.Fatalf("Want version 1 but got %d\n", comment.Version)
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	var tests = []struct {
+		responseCode int
+	}{
+		{responseCode: http.StatusNoContent},
+		{responseCode: http.StatusConflict},
+	}
+
+	for testNumber, test := range tests {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "DELETE" {
+				t.Errorf("Test %d: want DELETE but found %s\n", testNumber, r.Method)
+			}
+			if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/comments/3" {
+				t.Errorf("Test %d: want comment path but got %s\n", testNumber, r.URL.Path)
+			}
+			if version := r.URL.Query().Get("version"); version != "2" {
+				t.Errorf("Test %d: want version 2 but got %s\n", testNumber, version)
+			}
+			w.WriteHeader(test.responseCode)
+		}))
+		defer testServer.Close()
+
+		url, _ := url.Parse(testServer.URL)
+		stashClient := NewClient("u", "p", url)
+		err := stashClient.DeleteComment("PROJ", "slug", "7", 3, 2)
+		if test.responseCode != http.StatusNoContent {
+			if !IsConflict(err) {
+				t.Fatalf("Test %d: want conflict but got %v\n", testNumber, err)
+			}
+		} else if err != nil {
+			t.Fatalf("Test %d: not expecting error: %v\n", testNumber, err)
+		}
+	}
+}
+
+func TestReplyToComment(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if want := `{"text":"fixed","parent":{"id":3}}`; string(body) != want {
+			t.Errorf("Want %s but got %s\n", want, body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 4, "text": "fixed"}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	if _, err := stashClient.ReplyToComment("PROJ", "slug", "7", 3, "fixed"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
+
+func TestGetAllComments(t *testing.T) {
+	pages := []string{
+		`{"isLastPage": false, "nextPageStart": 2, "values": [
+			{"action": "COMMENTED", "commentAction": "ADDED", "comment": {"id": 4, "text": "fixed"}},
+			{"action": "APPROVED"}
+		]}`,
+		`{"isLastPage": true, "values": [
+			{"action": "COMMENTED", "commentAction": "ADDED", "comment": {"id": 3, "text": "typo", "comments": [{"id": 4, "text": "fixed"}]}},
+			{"action": "COMMENTED", "commentAction": "ADDED", "comment": {"id": 1, "text": "build passing"}}
+		]}`,
+	}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/activities" {
+			t.Errorf("Want activities path but got %s\n", r.URL.Path)
+		}
+		if r.URL.Query().Get("start") == "2" {
+			fmt.Fprint(w, pages[1])
+			return
+		}
+		fmt.Fprint(w, pages[0])
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	comments, err := stashClient.GetAllComments("PROJ", "slug", "7")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(comments) != 2 || comments[0].ID != 1 || comments[1].ID != 3 || comments[1].Comments[0].ID != 4 {
+		t.Fatalf("Want comments 1 and 3 with reply 4 but got %+v\n", comments)
+	}
+}
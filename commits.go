@@ -0,0 +1,188 @@
+package stash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// CommitMerges tells whether commit listings include merge commits.
+type CommitMerges string
+
+// Merge commit filters.
+const (
+	MergesExclude CommitMerges = "exclude"
+	MergesOnly    CommitMerges = "only"
+	MergesInclude CommitMerges = "include"
+)
+
+// CommitOptions filter the commits of a repository.  The zero value lists the
+// history of the default branch, merges included.
+type CommitOptions struct {
+	// Since excludes the commits reachable from it and Until, a commit or a
+	// ref, is where the history starts.
+	Since string
+	Until string
+	// Path limits the history to commits touching a file or a directory.
+	Path   string
+	Merges CommitMerges
+	// FollowRenames follows Path, which must be a file, across renames.
+	FollowRenames bool
+	// IgnoreMissing lists nothing, rather than failing, when Since or Until
+	// does not exist.
+	IgnoreMissing bool
+}
+
+func (options CommitOptions) query() url.Values {
+	query := url.Values{}
+	if options.Since != "" {
+		query.Set("since", options.Since)
+	}
+	if options.Until != "" {
+		query.Set("until", options.Until)
+	}
+	if options.Path != "" {
+		query.Set("path", options.Path)
+	}
+	if options.Merges != "" {
+		query.Set("merges", string(options.Merges))
+	}
+	if options.FollowRenames {
+		query.Set("followRenames", "true")
+	}
+	if options.IgnoreMissing {
+		query.Set("ignoreMissing", "true")
+	}
+	return query
+}
+
+// GetCommitsWithOptions returns the commits of a repository matching options,
+// newest first.
+func (client Client) GetCommitsWithOptions(projectKey, repositorySlug string, options CommitOptions) (Commits, error) {
+	return client.GetCommitsWithOptionsContext(context.Background(), projectKey, repositorySlug, options)
+}
+
+// GetCommitsWithOptionsContext is like GetCommitsWithOptions but uses ctx for every page it requests.
+func (client Client) GetCommitsWithOptionsContext(ctx context.Context, projectKey, repositorySlug string, options CommitOptions) (Commits, error) {
+	var commits Commits
+	it := client.paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/commits", projectKey, repositorySlug), options.query(), PageOptions{}, func(responseCode int) string {
+		switch {
+		case responseCode == http.StatusBadRequest:
+			return "Bad Request"
+		case responseCode == http.StatusUnauthorized:
+			return "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			return "Not found"
+		}
+		return "unhandled reason"
+	})
+	for it.Next() {
+		var commit Commit
+		if err := it.Decode(&commit); err != nil {
+			return Commits{}, err
+		}
+		commits.Commits = append(commits.Commits, commit)
+	}
+	if err := it.Err(); err != nil {
+		return Commits{}, err
+	}
+	return commits, nil
+}
+
+// GetPullRequestCommits returns the commits a pull request would merge into its
+// target branch, newest first.
+func (client Client) GetPullRequestCommits(projectKey, repositorySlug string, pullRequestID int) ([]Commit, error) {
+	return client.GetPullRequestCommitsContext(context.Background(), projectKey, repositorySlug, pullRequestID)
+}
+
+// GetPullRequestCommitsContext is like GetPullRequestCommits but uses ctx for every page it requests.
+func (client Client) GetPullRequestCommitsContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) ([]Commit, error) {
+	var commits []Commit
+	it := client.Paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/commits", projectKey, repositorySlug, pullRequestID), nil, PageOptions{})
+	for it.Next() {
+		var commit Commit
+		if err := it.Decode(&commit); err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// GetPullRequestJiraKeys returns the Jira issue keys referenced by the commits
+// of a pull request, without duplicates, in the order the commits are listed.
+func (client Client) GetPullRequestJiraKeys(projectKey, repositorySlug string, pullRequestID int) ([]string, error) {
+	return client.GetPullRequestJiraKeysContext(context.Background(), projectKey, repositorySlug, pullRequestID)
+}
+
+// GetPullRequestJiraKeysContext is like GetPullRequestJiraKeys but uses ctx for every page it requests.
+func (client Client) GetPullRequestJiraKeysContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) ([]string, error) {
+	commits, err := client.GetPullRequestCommitsContext(ctx, projectKey, repositorySlug, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	return JiraKeys(commits), nil
+}
+
+// JiraKeys returns the Jira issue keys referenced by commits, without
+// duplicates, in order of first appearance.
+func JiraKeys(commits []Commit) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, commit := range commits {
+		for _, key := range commit.Attributes.JiraKeys {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// GetCommitChanges returns the files changed by a commit, compared with its
+// first parent.
+func (client Client) GetCommitChanges(projectKey, repositorySlug, commitHash string) ([]Change, error) {
+	return client.GetCommitChangesContext(context.Background(), projectKey, repositorySlug, commitHash)
+}
+
+// GetCommitChangesContext is like GetCommitChanges but uses ctx for every page it requests.
+func (client Client) GetCommitChangesContext(ctx context.Context, projectKey, repositorySlug, commitHash string) ([]Change, error) {
+	var changes []Change
+	it := client.Paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/commits/%s/changes", projectKey, repositorySlug, commitHash), nil, PageOptions{})
+	for it.Next() {
+		var change Change
+		if err := it.Decode(&change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// GetCommitDiff returns the diff of every file changed by a commit.
+func (client Client) GetCommitDiff(projectKey, repositorySlug, commitHash string, options DiffOptions) (Diffs, error) {
+	return client.GetCommitDiffContext(context.Background(), projectKey, repositorySlug, commitHash, options)
+}
+
+// GetCommitDiffContext is like GetCommitDiff but uses ctx for the request.
+func (client Client) GetCommitDiffContext(ctx context.Context, projectKey, repositorySlug, commitHash string, options DiffOptions) (Diffs, error) {
+	return client.getDiff(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/commits/%s/diff", projectKey, repositorySlug, commitHash), options.query())
+}
+
+// GetCommitFileDiff returns the diff of a single file changed by a commit.
+func (client Client) GetCommitFileDiff(projectKey, repositorySlug, commitHash, path string, options DiffOptions) (Diffs, error) {
+	return client.GetCommitFileDiffContext(context.Background(), projectKey, repositorySlug, commitHash, path, options)
+}
+
+// GetCommitFileDiffContext is like GetCommitFileDiff but uses ctx for the request.
+func (client Client) GetCommitFileDiffContext(ctx context.Context, projectKey, repositorySlug, commitHash, path string, options DiffOptions) (Diffs, error) {
+	return client.getDiff(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/commits/%s/diff/%s", projectKey, repositorySlug, commitHash, escapePath(path)), options.query())
+}
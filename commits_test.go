@@ -0,0 +1,172 @@
+package stash
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGetPullRequestCommits(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/commits" {
+			t.Errorf("Want commits path but got %s\n", r.URL.Path)
+		}
+		if r.URL.Query().Get("start") == "2" {
+			fmt.Fprint(w, `{
+    "isLastPage": true,
+    "values": [
+        {"id": "a1b2", "displayId": "a1b2", "attributes": {"jira-key": ["PROJ-1"]}}
+    ]
+}`)
+			return
+		}
+		fmt.Fprint(w, `{
+    "isLastPage": false,
+    "nextPageStart": 2,
+    "values": [
+        {"id": "e5f6", "displayId": "e5f6", "author": {"name": "bob"}, "attributes": {"jira-key": ["PROJ-2", "PROJ-1"]}},
+        {"id": "c3d4", "displayId": "c3d4", "attributes": {}}
+    ]
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	commits, err := stashClient.GetPullRequestCommits("PROJ", "slug", 7)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits) != 3 || commits[0].Author.Name != "bob" || commits[2].ID != "a1b2" {
+		t.Fatalf("Want 3 commits from both pages but got %+v\n", commits)
+	}
+
+	keys, err := stashClient.GetPullRequestJiraKeys("PROJ", "slug", 7)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if fmt.Sprint(keys) != "[PROJ-2 PROJ-1]" {
+		t.Fatalf("Want [PROJ-2 PROJ-1] but got %v\n", keys)
+	}
+}
+
+func TestGetCommitChanges(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/commits/a1b2/changes" {
+			t.Errorf("Want changes path but got %s\n", r.URL.Path)
+		}
+		fmt.Fprint(w, `{
+    "isLastPage": true,
+    "values": [
+        {"contentId": "c1", "fromContentId": "c0", "path": {"toString": "README.md"}, "type": "MODIFY", "nodeType": "FILE"},
+        {"contentId": "c2", "path": {"toString": "docs/usage.md"}, "type": "ADD", "nodeType": "FILE"}
+    ]
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	changes, err := stashClient.GetCommitChanges("PROJ", "slug", "a1b2")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(changes) != 2 || changes[0].Type != ChangeModify || changes[1].Path.ToString != "docs/usage.md" {
+		t.Fatalf("Want README.md modified and docs/usage.md added but got %+v\n", changes)
+	}
+}
+
+func TestGetCommitDiff(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/commits/a1b2/diff/README.md" {
+			t.Errorf("Want file diff path but got %s\n", r.URL.Path)
+		}
+		if r.URL.Query().Get("contextLines") != "3" {
+			t.Errorf("Want contextLines=3 but got %s\n", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `{
+    "fromHash": "c3d4",
+    "toHash": "a1b2",
+    "contextLines": 3,
+    "diffs": [
+        {"source": {"toString": "README.md"}, "destination": {"toString": "README.md"}, "hunks": [
+            {"sourceLine": 1, "sourceSpan": 1, "destinationLine": 1, "destinationSpan": 1, "segments": [
+                {"type": "REMOVED", "lines": [{"source": 1, "destination": 1, "line": "widget"}]},
+                {"type": "ADDED", "lines": [{"source": 2, "destination": 1, "line": "widget, improved"}]}
+            ]}
+        ]}
+    ]
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	diffs, err := stashClient.GetCommitFileDiff("PROJ", "slug", "a1b2", "README.md", DiffOptions{ContextLines: 3})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if diffs.FromHash != "c3d4" || len(diffs.Diffs) != 1 || len(diffs.Diffs[0].Hunks[0].Segments) != 2 {
+		t.Fatalf("Want one README.md diff with two segments but got %+v\n", diffs)
+	}
+}
+
+func TestGetCommitFileDiffEscapesPath(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/rest/api/1.0/projects/PROJ/repos/slug/commits/a1b2/diff/docs/100%25%20done%3F%231.md" {
+			t.Errorf("Want escaped file diff path but got %s\n", r.URL.EscapedPath())
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("Want no query but got %s\n", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `{"diffs": []}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	if _, err := stashClient.GetCommitFileDiff("PROJ", "slug", "a1b2", "docs/100% done?#1.md", DiffOptions{}); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
+
+func TestGetCommitsWithOptions(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/commits" {
+			t.Errorf("Want commits path but got %s\n", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("path") != "services/billing" || query.Get("merges") != "exclude" || query.Get("until") != "master" {
+			t.Errorf("Want path, merges and until filters but got %s\n", r.URL.RawQuery)
+		}
+		if _, ok := query["since"]; ok {
+			t.Errorf("Want no since but got %s\n", r.URL.RawQuery)
+		}
+		if query.Get("followRenames") != "" || query.Get("ignoreMissing") != "true" {
+			t.Errorf("Want only ignoreMissing but got %s\n", r.URL.RawQuery)
+		}
+		if query.Get("start") == "1" {
+			fmt.Fprint(w, `{"isLastPage": true, "values": [{"id": "c3d4", "displayId": "c3d4"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"isLastPage": false, "nextPageStart": 1, "values": [{"id": "a1b2", "displayId": "a1b2", "message": "Bill monthly"}]}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	commits, err := stashClient.GetCommitsWithOptions("PROJ", "slug", CommitOptions{
+		Until:         "master",
+		Path:          "services/billing",
+		Merges:        MergesExclude,
+		IgnoreMissing: true,
+	})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits.Commits) != 2 || commits.Commits[0].Message != "Bill monthly" || commits.Commits[1].ID != "c3d4" {
+		t.Fatalf("Want 2 commits from both pages but got %+v\n", commits.Commits)
+	}
+}
@@ -0,0 +1,66 @@
+package stash
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// CompareCommits returns the commits reachable from from but not from to,
+// newest first.  from and to are branches, tags or commit IDs, so that
+// CompareCommits("PROJ", "slug", "master", "v1.2", "") tells what master has
+// that the v1.2 tag lacks.  When from lives in another repository, such as a
+// fork, fromRepository names it as projectKey/repositorySlug.
+func (client Client) CompareCommits(projectKey, repositorySlug, from, to, fromRepository string) ([]Commit, error) {
+	return client.CompareCommitsContext(context.Background(), projectKey, repositorySlug, from, to, fromRepository)
+}
+
+// CompareCommitsContext is like CompareCommits but uses ctx for every page it requests.
+func (client Client) CompareCommitsContext(ctx context.Context, projectKey, repositorySlug, from, to, fromRepository string) ([]Commit, error) {
+	var commits []Commit
+	it := client.Paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/compare/commits", projectKey, repositorySlug), compareQuery(from, to, fromRepository), PageOptions{})
+	for it.Next() {
+		var commit Commit
+		if err := it.Decode(&commit); err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// CompareChanges returns the files changed between to and from, as
+// CompareCommits takes them.
+func (client Client) CompareChanges(projectKey, repositorySlug, from, to, fromRepository string) ([]Change, error) {
+	return client.CompareChangesContext(context.Background(), projectKey, repositorySlug, from, to, fromRepository)
+}
+
+// CompareChangesContext is like CompareChanges but uses ctx for every page it requests.
+func (client Client) CompareChangesContext(ctx context.Context, projectKey, repositorySlug, from, to, fromRepository string) ([]Change, error) {
+	var changes []Change
+	it := client.Paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/compare/changes", projectKey, repositorySlug), compareQuery(from, to, fromRepository), PageOptions{})
+	for it.Next() {
+		var change Change
+		if err := it.Decode(&change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func compareQuery(from, to, fromRepository string) url.Values {
+	query := url.Values{}
+	query.Set("from", from)
+	query.Set("to", to)
+	if fromRepository != "" {
+		query.Set("fromRepo", fromRepository)
+	}
+	return query
+}
@@ -0,0 +1,60 @@
+package stash
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCompareCommits(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/compare/commits" {
+			t.Errorf("Want compare commits path but got %s\n", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("from") != "master" || query.Get("to") != "refs/tags/v1.2" || query.Get("fromRepo") != "" {
+			t.Errorf("Want master compared to v1.2 but got %s\n", r.URL.RawQuery)
+		}
+		if query.Get("start") == "1" {
+			fmt.Fprint(w, `{"isLastPage": true, "values": [{"id": "a1b2"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"isLastPage": false, "nextPageStart": 1, "values": [{"id": "c3d4"}]}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	commits, err := stashClient.CompareCommits("PROJ", "slug", "master", "refs/tags/v1.2", "")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits) != 2 || commits[0].ID != "c3d4" || commits[1].ID != "a1b2" {
+		t.Fatalf("Want c3d4 and a1b2 but got %+v\n", commits)
+	}
+}
+
+func TestCompareChangesAcrossFork(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/compare/changes" {
+			t.Errorf("Want compare changes path but got %s\n", r.URL.Path)
+		}
+		if fromRepo := r.URL.Query().Get("fromRepo"); fromRepo != "~ALICE/slug" {
+			t.Errorf("Want ~ALICE/slug but got %s\n", fromRepo)
+		}
+		fmt.Fprint(w, `{"isLastPage": true, "values": [{"type": "ADD", "nodeType": "FILE", "path": {"toString": "docs/new.md"}}]}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	changes, err := stashClient.CompareChanges("PROJ", "slug", "fix", "master", "~ALICE/slug")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(changes) != 1 || changes[0].Type != ChangeAdd || changes[0].Path.ToString != "docs/new.md" {
+		t.Fatalf("Want docs/new.md added but got %+v\n", changes)
+	}
+}
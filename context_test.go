@@ -0,0 +1,62 @@
+package stash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestGetPullRequestsContextCancelsPaging(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 3 {
+			cancel()
+		}
+		fmt.Fprintf(w, `{"isLastPage": false, "nextPageStart": %d, "values": [{"id": %d}]}`, requests, requests)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	_, err := stashClient.GetPullRequestsContext(ctx, "PROJ", "slug", "OPEN")
+	if err == nil {
+		t.Fatalf("Want error after cancel but got none\n")
+	}
+	if requests != 3 {
+		t.Fatalf("Want 3 requests but got %d\n", requests)
+	}
+}
+
+func TestGetRepositoryContextDeadlineStopsRetries(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer testServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	start := time.Now()
+	_, err := stashClient.GetRepositoryContext(ctx, "PROJ", "slug")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Want %v but got %v\n", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Want early return on deadline but took %v\n", elapsed)
+	}
+}
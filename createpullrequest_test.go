@@ -1,6 +1,7 @@
 package stash
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -288,3 +289,25 @@ func TestCreatePullRequest(t *testing.T) {
 		t.Fatalf("Want develop but got %v\n", pullRequest.ToRef)
 	}
 }
+
+func TestPullRequestIDField(t *testing.T) {
+	var pullRequest PullRequest
+	if err := json.Unmarshal([]byte(createPullRequestResponse), &pullRequest); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if pullRequest.ID != 2 {
+		t.Fatalf("Want 2 but got %v\n", pullRequest.ID)
+	}
+
+	data, err := json.Marshal(pullRequest)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if fields["id"] != float64(2) {
+		t.Fatalf("Want id 2 in %s\n", data)
+	}
+}
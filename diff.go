@@ -0,0 +1,192 @@
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Values of DiffOptions.Whitespace.
+const (
+	WhitespaceShow      = "show"
+	WhitespaceIgnoreAll = "ignore-all"
+)
+
+// NoContextLines asks for diffs without context lines around changes.  It
+// exists because a zero DiffOptions.ContextLines means Stash's default.
+const NoContextLines = -1
+
+type (
+	// DiffOptions tune a diff.  The zero value asks for Stash's defaults.
+	DiffOptions struct {
+		// ContextLines is the number of unchanged lines shown around changes.
+		// Zero leaves the choice to Stash; use NoContextLines, not 0, to ask for
+		// none.
+		ContextLines int
+		Whitespace   string
+	}
+
+	// Diffs is the diff between two revisions, one Diff per file.
+	Diffs struct {
+		FromHash     string `json:"fromHash"`
+		ToHash       string `json:"toHash"`
+		ContextLines int    `json:"contextLines"`
+		Whitespace   string `json:"whitespace"`
+		Diffs        []Diff `json:"diffs"`
+		Truncated    bool   `json:"truncated"`
+	}
+
+	// Diff is the diff of a single file.  Source is nil for added files and
+	// Destination is nil for deleted ones.
+	Diff struct {
+		Source      *Path  `json:"source"`
+		Destination *Path  `json:"destination"`
+		Hunks       []Hunk `json:"hunks"`
+		Binary      bool   `json:"binary,omitempty"`
+		Truncated   bool   `json:"truncated"`
+	}
+
+	// Hunk is a run of changed lines with their context.
+	Hunk struct {
+		SourceLine      int       `json:"sourceLine"`
+		SourceSpan      int       `json:"sourceSpan"`
+		DestinationLine int       `json:"destinationLine"`
+		DestinationSpan int       `json:"destinationSpan"`
+		Segments        []Segment `json:"segments"`
+		Truncated       bool      `json:"truncated"`
+	}
+
+	// Segment is a run of lines of the same type: LineAdded, LineRemoved or
+	// LineContext.
+	Segment struct {
+		Type      string     `json:"type"`
+		Lines     []DiffLine `json:"lines"`
+		Truncated bool       `json:"truncated"`
+	}
+
+	// DiffLine is a line of a diff with its numbers in the source and
+	// destination files.
+	DiffLine struct {
+		Source      int    `json:"source"`
+		Destination int    `json:"destination"`
+		Line        string `json:"line"`
+		Truncated   bool   `json:"truncated"`
+	}
+)
+
+func (options DiffOptions) query() url.Values {
+	query := url.Values{}
+	switch {
+	case options.ContextLines == NoContextLines:
+		query.Set("contextLines", "0")
+	case options.ContextLines > 0:
+		query.Set("contextLines", strconv.Itoa(options.ContextLines))
+	}
+	if options.Whitespace != "" {
+		query.Set("whitespace", options.Whitespace)
+	}
+	return query
+}
+
+// GetPullRequestDiff returns the diff of every file changed by a pull request.
+func (client Client) GetPullRequestDiff(projectKey, repositorySlug string, pullRequestID int, options DiffOptions) (Diffs, error) {
+	return client.GetPullRequestDiffContext(context.Background(), projectKey, repositorySlug, pullRequestID, options)
+}
+
+// GetPullRequestDiffContext is like GetPullRequestDiff but uses ctx for the request.
+func (client Client) GetPullRequestDiffContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int, options DiffOptions) (Diffs, error) {
+	return client.getDiff(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/diff", projectKey, repositorySlug, pullRequestID), options.query())
+}
+
+// GetPullRequestFileDiff returns the diff of a single file changed by a pull
+// request.
+func (client Client) GetPullRequestFileDiff(projectKey, repositorySlug string, pullRequestID int, path string, options DiffOptions) (Diffs, error) {
+	return client.GetPullRequestFileDiffContext(context.Background(), projectKey, repositorySlug, pullRequestID, path, options)
+}
+
+// GetPullRequestFileDiffContext is like GetPullRequestFileDiff but uses ctx for the request.
+func (client Client) GetPullRequestFileDiffContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int, path string, options DiffOptions) (Diffs, error) {
+	return client.getDiff(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/diff/%s", projectKey, repositorySlug, pullRequestID, escapePath(path)), options.query())
+}
+
+// GetPullRequestRawDiff returns the diff of a pull request as unified diff text.
+func (client Client) GetPullRequestRawDiff(projectKey, repositorySlug string, pullRequestID int, options DiffOptions) ([]byte, error) {
+	return client.GetPullRequestRawDiffContext(context.Background(), projectKey, repositorySlug, pullRequestID, options)
+}
+
+// GetPullRequestRawDiffContext is like GetPullRequestRawDiff but uses ctx for the request.
+func (client Client) GetPullRequestRawDiffContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int, options DiffOptions) ([]byte, error) {
+	u := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d.diff", client.baseURL.String(), projectKey, repositorySlug, pullRequestID)
+	if query := options.query().Encode(); query != "" {
+		u += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return nil, newAPIError(req, responseCode, data, reason)
+	}
+	return data, nil
+}
+
+// escapePath escapes each segment of a file path for use in a URL, keeping the
+// slashes between them.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// getDiff fetches the JSON diff at path, relative to the base URL.
+func (client Client) getDiff(ctx context.Context, path string, query url.Values) (Diffs, error) {
+	u := client.baseURL.String() + path
+	if encoded := query.Encode(); encoded != "" {
+		u += "?" + encoded
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return Diffs{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return Diffs{}, err
+	}
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "Bad request"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return Diffs{}, newAPIError(req, responseCode, data, reason)
+	}
+
+	var diffs Diffs
+	err = json.Unmarshal(data, &diffs)
+	return diffs, err
+}
@@ -0,0 +1,144 @@
+package stash
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGetPullRequestDiff(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Want GET but found %s\n", r.Method)
+		}
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/diff/src/main.go" {
+			t.Errorf("Want diff path but got %s\n", r.URL.Path)
+		}
+		if query := r.URL.Query(); query.Get("contextLines") != "0" || query.Get("whitespace") != "ignore-all" {
+			t.Errorf("Want no context lines ignoring whitespace but got %s\n", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `{
+    "fromHash": "aaa",
+    "toHash": "bbb",
+    "contextLines": 0,
+    "whitespace": "IGNORE_ALL",
+    "diffs": [
+        {
+            "source": {"components": ["src", "main.go"], "name": "main.go", "toString": "src/main.go"},
+            "destination": {"components": ["src", "main.go"], "name": "main.go", "toString": "src/main.go"},
+            "hunks": [
+                {
+                    "sourceLine": 12,
+                    "sourceSpan": 1,
+                    "destinationLine": 12,
+                    "destinationSpan": 2,
+                    "segments": [
+                        {"type": "REMOVED", "lines": [{"source": 12, "destination": 12, "line": "\treturn nil"}]},
+                        {"type": "ADDED", "lines": [
+                            {"source": 13, "destination": 12, "line": "\tlog.Print(\"done\")"},
+                            {"source": 13, "destination": 13, "line": "\treturn err"}
+                        ]}
+                    ],
+                    "truncated": false
+                }
+            ],
+            "truncated": false
+        }
+    ],
+    "truncated": false
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	diffs, err := stashClient.GetPullRequestFileDiff("PROJ", "slug", 7, "src/main.go", DiffOptions{ContextLines: NoContextLines, Whitespace: WhitespaceIgnoreAll})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if diffs.FromHash != "aaa" || diffs.ToHash != "bbb" || len(diffs.Diffs) != 1 {
+		t.Fatalf("Want one diff from aaa to bbb but got %+v\n", diffs)
+	}
+	diff := diffs.Diffs[0]
+	if diff.Source.ToString != "src/main.go" || diff.Destination.Name != "main.go" {
+		t.Fatalf("Want src/main.go but got %+v\n", diff)
+	}
+	hunk := diff.Hunks[0]
+	if hunk.SourceLine != 12 || hunk.DestinationSpan != 2 || len(hunk.Segments) != 2 {
+		t.Fatalf("Want one hunk at line 12 but got %+v\n", hunk)
+	}
+	if added := hunk.Segments[1]; added.Type != LineAdded || added.Lines[1].Destination != 13 || added.Lines[1].Line != "\treturn err" {
+		t.Fatalf("Want two added lines but got %+v\n", added)
+	}
+}
+
+func TestGetPullRequestDiffDefaults(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/diff" {
+			t.Errorf("Want diff path but got %s\n", r.URL.Path)
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("Want no query but got %s\n", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `{"diffs": [{"source": null, "destination": {"toString": "new.txt"}, "hunks": []}]}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	diffs, err := stashClient.GetPullRequestDiff("PROJ", "slug", 7, DiffOptions{})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(diffs.Diffs) != 1 || diffs.Diffs[0].Source != nil {
+		t.Fatalf("Want an added file but got %+v\n", diffs)
+	}
+}
+
+func TestGetPullRequestFileDiffEscapesPath(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/diff/docs/my%20file%231%3F%25.md" {
+			t.Errorf("Want escaped diff path but got %s\n", r.URL.EscapedPath())
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("Want no query but got %s\n", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `{"diffs": []}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	if _, err := stashClient.GetPullRequestFileDiff("PROJ", "slug", 7, "docs/my file#1?%.md", DiffOptions{}); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
+
+func TestGetPullRequestRawDiff(t *testing.T) {
+	raw := "diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -1,1 +1,1 @@\n-widget\n+widget, improved\n"
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7.diff" {
+			t.Errorf("Want raw diff path but got %s\n", r.URL.Path)
+		}
+		if r.URL.Query().Get("contextLines") != "3" {
+			t.Errorf("Want 3 context lines but got %s\n", r.URL.RawQuery)
+		}
+		if r.Header.Get("Accept") != "text/plain" {
+			t.Errorf("Want text/plain but got %s\n", r.Header.Get("Accept"))
+		}
+		fmt.Fprint(w, raw)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	data, err := stashClient.GetPullRequestRawDiff("PROJ", "slug", 7, DiffOptions{ContextLines: 3})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if string(data) != raw {
+		t.Fatalf("Want %q but got %q\n", raw, data)
+	}
+}
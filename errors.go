@@ -0,0 +1,152 @@
+package stash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type (
+	// APIError is returned when Stash answers a request with an unexpected status
+	// code.  Use errors.As to inspect it, or one of the Is predicates below.
+	APIError struct {
+		StatusCode int
+		Method     string
+		URL        string
+		// Reason is this package's explanation of the status code for the call.
+		Reason string
+		// Errors holds the error payload returned by Stash, if any.
+		Errors []ErrorDetail
+	}
+
+	// ErrorDetail is one entry of the errors array in a Stash error response.
+	ErrorDetail struct {
+		Context       string `json:"context"`
+		Message       string `json:"message"`
+		ExceptionName string `json:"exceptionName"`
+		// CurrentVersion and ExpectedVersion are set when an update was rejected
+		// because it was based on an out of date version.
+		CurrentVersion  *int `json:"currentVersion,omitempty"`
+		ExpectedVersion *int `json:"expectedVersion,omitempty"`
+		// Conflicted and Vetoes are set when a merge was refused.
+		Conflicted bool        `json:"conflicted,omitempty"`
+		Vetoes     []MergeVeto `json:"vetoes,omitempty"`
+	}
+
+	stashError struct {
+		Errors []ErrorDetail `json:"errors"`
+	}
+)
+
+// newAPIError describes the response to req, parsing the Stash error payload
+// from body when there is one.
+func newAPIError(req *http.Request, statusCode int, body []byte, reason string) *APIError {
+	e := &APIError{
+		StatusCode: statusCode,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Reason:     reason,
+	}
+	var payload stashError
+	if err := json.Unmarshal(body, &payload); err == nil {
+		e.Errors = payload.Errors
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	var messages []string
+	for _, detail := range e.Errors {
+		if detail.Message != "" {
+			messages = append(messages, detail.Message)
+		}
+	}
+	message := e.Reason
+	if len(messages) > 0 {
+		message = strings.Join(messages, " ")
+	}
+	return fmt.Sprintf("%s %s: %s (%d)", e.Method, e.URL, message, e.StatusCode)
+}
+
+// hasException reports whether any error detail names an exception with suffix.
+func (e *APIError) hasException(suffix string) bool {
+	for _, detail := range e.Errors {
+		if strings.HasSuffix(detail.ExceptionName, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func statusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is an APIError for a missing resource.
+func IsNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether err is an APIError for a request that conflicts
+// with the current state of the resource.
+func IsConflict(err error) bool {
+	return statusCode(err) == http.StatusConflict
+}
+
+// IsUnauthorized reports whether err is an APIError for missing or rejected
+// credentials.
+func IsUnauthorized(err error) bool {
+	return statusCode(err) == http.StatusUnauthorized
+}
+
+// IsForbidden reports whether err is an APIError for a user lacking permission.
+func IsForbidden(err error) bool {
+	return statusCode(err) == http.StatusForbidden
+}
+
+// IsStaleVersion reports whether err is an APIError for an update carrying an
+// out of date version, such as a pull request changed since it was read.
+func IsStaleVersion(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		return false
+	}
+	for _, detail := range apiErr.Errors {
+		if detail.ExpectedVersion != nil {
+			return true
+		}
+	}
+	return apiErr.hasException("OutOfDateException")
+}
+
+// IsMergeVetoed reports whether err is an APIError for a merge refused because
+// of merge checks or conflicts.  The vetoes are in the Errors of the APIError.
+func IsMergeVetoed(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		return false
+	}
+	for _, detail := range apiErr.Errors {
+		if detail.Conflicted || len(detail.Vetoes) > 0 {
+			return true
+		}
+	}
+	return apiErr.hasException("MergeVetoedException")
+}
+
+// IsRepositoryExists reports whether err is an APIError for creating a repository
+// that already exists.
+func IsRepositoryExists(err error) bool {
+	return IsConflict(err)
+}
+
+// IsRepositoryNotFound reports whether err is an APIError for a missing repository.
+func IsRepositoryNotFound(err error) bool {
+	return IsNotFound(err)
+}
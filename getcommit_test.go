@@ -73,6 +73,12 @@ func TestGetCommit(t *testing.T) {
 	if commit.AuthorTimestamp != 1459802103000 {
 		t.Fatalf("Want 1459802103000 but got %d\n", commit.AuthorTimestamp)
 	}
+	if commit.Message != "Updating develop poms" {
+		t.Fatalf("Want Updating develop poms but got %s\n", commit.Message)
+	}
+	if len(commit.Parents) != 1 || commit.Parents[0].DisplayID != "e00a056" {
+		t.Fatalf("Want parent e00a056 but got %+v\n", commit.Parents)
+	}
 }
 
 func TestGetCommit404(t *testing.T) {
@@ -21,10 +21,10 @@ func TestGetCommits(t *testing.T) {
 			t.Fatalf("Want since=6782bf94782450a4e6a0d548e4c803692ca38b94, but found %s\n", url.Query()["since"])
 		}
 		if url.Query()["until"][0] != "38b94f94782450a4e6a0d548e4c803692ca6782b" {
-			t.Fatalf("Want until=38b94f94782450a4e6a0d548e4c803692ca6782b&limit=1000 but found %s\n", url.Query()["until"])
+			t.Fatalf("Want until=38b94f94782450a4e6a0d548e4c803692ca6782b but found %s\n", url.Query()["until"])
 		}
-		if url.Query()["limit"][0] != "1000" {
-			t.Fatalf("Want limit=1000 but found %s\n", url.Query()["limit"])
+		if url.Query()["limit"][0] != "25" {
+			t.Fatalf("Want limit=25 but found %s\n", url.Query()["limit"])
 		}
 		if r.Header.Get("Accept") != "application/json" {
 			t.Fatalf("Want application/json but found %s\n", r.Header.Get("Accept"))
@@ -33,7 +33,7 @@ func TestGetCommits(t *testing.T) {
 			t.Fatalf("Want Basic dTpw but found %s\n", r.Header.Get("Authorization"))
 		}
 		_, _ = w.Write(
-			[]byte(`{ "values": [{
+			[]byte(`{ "isLastPage": true, "values": [{
 	  "id": "f94786782b2450a4e6a0d548e4c803692ca38b94",
 	  "displayId": "f947867",
 	  "author": {
@@ -0,0 +1,279 @@
+package stash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Insight report results.
+const (
+	InsightPass = "PASS"
+	InsightFail = "FAIL"
+)
+
+// Insight data types.
+const (
+	InsightBoolean    = "BOOLEAN"
+	InsightDate       = "DATE"
+	InsightDuration   = "DURATION"
+	InsightLink       = "LINK"
+	InsightNumber     = "NUMBER"
+	InsightPercentage = "PERCENTAGE"
+	InsightText       = "TEXT"
+)
+
+// Annotation severities.
+const (
+	SeverityLow    = "LOW"
+	SeverityMedium = "MEDIUM"
+	SeverityHigh   = "HIGH"
+)
+
+// Annotation types.
+const (
+	AnnotationVulnerability = "VULNERABILITY"
+	AnnotationCodeSmell     = "CODE_SMELL"
+	AnnotationBug           = "BUG"
+)
+
+// maxAnnotationsPerReport is the number of annotations Stash keeps on a report,
+// which is also the most it accepts in one request.
+const maxAnnotationsPerReport = 1000
+
+type (
+	// InsightReport is a Code Insights report on a commit, shown on the pull
+	// requests that contain it.
+	InsightReport struct {
+		Key         string        `json:"key,omitempty"`
+		Title       string        `json:"title"`
+		Details     string        `json:"details,omitempty"`
+		Result      string        `json:"result,omitempty"`
+		Reporter    string        `json:"reporter,omitempty"`
+		Link        string        `json:"link,omitempty"`
+		LogoURL     string        `json:"logoUrl,omitempty"`
+		Data        []InsightData `json:"data,omitempty"`
+		CreatedDate int64         `json:"createdDate,omitempty"`
+	}
+
+	// InsightData is a field of a report.  Build it with the New...Data
+	// functions; decoded values are bools, float64s, strings or, for links,
+	// map[string]interface{}.
+	InsightData struct {
+		Title string      `json:"title"`
+		Type  string      `json:"type"`
+		Value interface{} `json:"value"`
+	}
+
+	// InsightLinkValue is the value of InsightLink data.
+	InsightLinkValue struct {
+		LinkText string `json:"linktext"`
+		Href     string `json:"href"`
+	}
+
+	// InsightAnnotation points at a line of a file, or at the whole file when
+	// Line is 0.
+	InsightAnnotation struct {
+		ExternalID string `json:"externalId,omitempty"`
+		Path       string `json:"path,omitempty"`
+		Line       int    `json:"line,omitempty"`
+		Message    string `json:"message"`
+		Severity   string `json:"severity"`
+		Type       string `json:"type,omitempty"`
+		Link       string `json:"link,omitempty"`
+	}
+
+	insightAnnotations struct {
+		Annotations []InsightAnnotation `json:"annotations"`
+	}
+)
+
+// NewBooleanData returns InsightBoolean data.
+func NewBooleanData(title string, value bool) InsightData {
+	return InsightData{Title: title, Type: InsightBoolean, Value: value}
+}
+
+// NewDateData returns InsightDate data.
+func NewDateData(title string, value time.Time) InsightData {
+	return InsightData{Title: title, Type: InsightDate, Value: value.UnixNano() / int64(time.Millisecond)}
+}
+
+// NewDurationData returns InsightDuration data.
+func NewDurationData(title string, value time.Duration) InsightData {
+	return InsightData{Title: title, Type: InsightDuration, Value: int64(value / time.Millisecond)}
+}
+
+// NewLinkData returns InsightLink data.
+func NewLinkData(title, linkText, href string) InsightData {
+	return InsightData{Title: title, Type: InsightLink, Value: InsightLinkValue{LinkText: linkText, Href: href}}
+}
+
+// NewNumberData returns InsightNumber data.
+func NewNumberData(title string, value float64) InsightData {
+	return InsightData{Title: title, Type: InsightNumber, Value: value}
+}
+
+// NewPercentageData returns InsightPercentage data, value being between 0 and
+// 100.
+func NewPercentageData(title string, value float64) InsightData {
+	return InsightData{Title: title, Type: InsightPercentage, Value: value}
+}
+
+// NewTextData returns InsightText data.
+func NewTextData(title, value string) InsightData {
+	return InsightData{Title: title, Type: InsightText, Value: value}
+}
+
+// CreateInsightReport creates or replaces the report with key on the given
+// commit hash.
+func (client Client) CreateInsightReport(projectKey, repositorySlug, commitHash, key string, report InsightReport) (InsightReport, error) {
+	return client.CreateInsightReportContext(context.Background(), projectKey, repositorySlug, commitHash, key, report)
+}
+
+// CreateInsightReportContext is like CreateInsightReport but uses ctx for the request.
+func (client Client) CreateInsightReportContext(ctx context.Context, projectKey, repositorySlug, commitHash, key string, report InsightReport) (InsightReport, error) {
+	reqBody, err := json.Marshal(report)
+	if err != nil {
+		return InsightReport{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "PUT", client.insightReportURL(projectKey, repositorySlug, commitHash, key), bytes.NewBuffer(reqBody))
+	if err != nil {
+		return InsightReport{}, err
+	}
+	req.Header.Set("Content-type", "application/json")
+	return client.insightReport(req)
+}
+
+// GetInsightReport returns the report with key on the given commit hash.
+func (client Client) GetInsightReport(projectKey, repositorySlug, commitHash, key string) (InsightReport, error) {
+	return client.GetInsightReportContext(context.Background(), projectKey, repositorySlug, commitHash, key)
+}
+
+// GetInsightReportContext is like GetInsightReport but uses ctx for the request.
+func (client Client) GetInsightReportContext(ctx context.Context, projectKey, repositorySlug, commitHash, key string) (InsightReport, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", client.insightReportURL(projectKey, repositorySlug, commitHash, key), nil)
+	if err != nil {
+		return InsightReport{}, err
+	}
+	return client.insightReport(req)
+}
+
+// DeleteInsightReport deletes the report with key on the given commit hash,
+// with its annotations.
+func (client Client) DeleteInsightReport(projectKey, repositorySlug, commitHash, key string) error {
+	return client.DeleteInsightReportContext(context.Background(), projectKey, repositorySlug, commitHash, key)
+}
+
+// DeleteInsightReportContext is like DeleteInsightReport but uses ctx for the request.
+func (client Client) DeleteInsightReportContext(ctx context.Context, projectKey, repositorySlug, commitHash, key string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", client.insightReportURL(projectKey, repositorySlug, commitHash, key), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// https://confluence.atlassian.com/cloudkb/xsrf-check-failed-when-calling-cloud-apis-826874382.html
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+	if responseCode != http.StatusNoContent {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return newAPIError(req, responseCode, data, reason)
+	}
+	return nil
+}
+
+// AddInsightAnnotations adds annotations to the report with key on the given
+// commit hash in a single request.  Stash keeps at most 1000 annotations on a
+// report, counting those added before, so more than that are refused without
+// sending anything.
+func (client Client) AddInsightAnnotations(projectKey, repositorySlug, commitHash, key string, annotations []InsightAnnotation) error {
+	return client.AddInsightAnnotationsContext(context.Background(), projectKey, repositorySlug, commitHash, key, annotations)
+}
+
+// AddInsightAnnotationsContext is like AddInsightAnnotations but uses ctx for the request.
+func (client Client) AddInsightAnnotationsContext(ctx context.Context, projectKey, repositorySlug, commitHash, key string, annotations []InsightAnnotation) error {
+	if len(annotations) > maxAnnotationsPerReport {
+		return fmt.Errorf("stash: a report holds at most %d annotations, got %d", maxAnnotationsPerReport, len(annotations))
+	}
+	return client.postAnnotations(ctx, client.insightReportURL(projectKey, repositorySlug, commitHash, key)+"/annotations", annotations)
+}
+
+func (client Client) postAnnotations(ctx context.Context, u string, annotations []InsightAnnotation) error {
+	reqBody, err := json.Marshal(insightAnnotations{Annotations: annotations})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-type", "application/json")
+
+	// https://confluence.atlassian.com/cloudkb/xsrf-check-failed-when-calling-cloud-apis-826874382.html
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+	if responseCode != http.StatusNoContent {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "Bad request"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return newAPIError(req, responseCode, data, reason)
+	}
+	return nil
+}
+
+func (client Client) insightReportURL(projectKey, repositorySlug, commitHash, key string) string {
+	return fmt.Sprintf("%s/rest/insights/1.0/projects/%s/repos/%s/commits/%s/reports/%s", client.baseURL.String(), projectKey, repositorySlug, commitHash, key)
+}
+
+// insightReport sends a report request and decodes the report.
+func (client Client) insightReport(req *http.Request) (InsightReport, error) {
+	req.Header.Set("Accept", "application/json")
+
+	// https://confluence.atlassian.com/cloudkb/xsrf-check-failed-when-calling-cloud-apis-826874382.html
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return InsightReport{}, err
+	}
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "Bad request"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return InsightReport{}, newAPIError(req, responseCode, data, reason)
+	}
+
+	var report InsightReport
+	err = json.Unmarshal(data, &report)
+	return report, err
+}
@@ -0,0 +1,101 @@
+package stash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCreateInsightReport(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			t.Errorf("Want PUT but found %s\n", r.Method)
+		}
+		if r.URL.Path != "/rest/insights/1.0/projects/PROJ/repos/slug/commits/a1b2c3/reports/sast" {
+			t.Errorf("Want report path but got %s\n", r.URL.Path)
+		}
+		var body struct {
+			Title string `json:"title"`
+			Data  []struct {
+				Type  string          `json:"type"`
+				Value json.RawMessage `json:"value"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Not expecting error: %v\n", err)
+		}
+		var values []string
+		for _, data := range body.Data {
+			values = append(values, data.Type+"="+string(data.Value))
+		}
+		want := `[BOOLEAN=true DATE=1500000000000 DURATION=90000 LINK={"linktext":"Job","href":"https://ci/1"} NUMBER=3 PERCENTAGE=87.5 TEXT="ok"]`
+		if fmt.Sprint(values) != want {
+			t.Errorf("Want %s but got %v\n", want, values)
+		}
+		fmt.Fprint(w, `{"key": "sast", "title": "Security scan", "result": "FAIL", "createdDate": 1500000001000, "data": [{"title": "Safe", "type": "BOOLEAN", "value": true}]}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	report, err := stashClient.CreateInsightReport("PROJ", "slug", "a1b2c3", "sast", InsightReport{
+		Title:  "Security scan",
+		Result: InsightFail,
+		Data: []InsightData{
+			NewBooleanData("Safe", true),
+			NewDateData("Scanned", time.Unix(1500000000, 0)),
+			NewDurationData("Took", 90*time.Second),
+			NewLinkData("Details", "Job", "https://ci/1"),
+			NewNumberData("Findings", 3),
+			NewPercentageData("Coverage", 87.5),
+			NewTextData("Status", "ok"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if report.Key != "sast" || report.Result != InsightFail || report.Data[0].Value != true {
+		t.Fatalf("Want the failed sast report but got %+v\n", report)
+	}
+}
+
+func TestAddInsightAnnotationsLimit(t *testing.T) {
+	var requests []int
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/insights/1.0/projects/PROJ/repos/slug/commits/a1b2c3/reports/sast/annotations" {
+			t.Errorf("Want annotations path but got %s\n", r.URL.Path)
+		}
+		var body insightAnnotations
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Not expecting error: %v\n", err)
+		}
+		requests = append(requests, len(body.Annotations))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	annotations := make([]InsightAnnotation, maxAnnotationsPerReport+1)
+	for i := range annotations {
+		annotations[i] = InsightAnnotation{Path: "main.go", Line: i + 1, Message: "unchecked error", Severity: SeverityHigh, Type: AnnotationBug}
+	}
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	if err := stashClient.AddInsightAnnotations("PROJ", "slug", "a1b2c3", "sast", annotations); err == nil {
+		t.Fatalf("Want error for more annotations than a report holds but got none\n")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("Want no requests but got %v\n", requests)
+	}
+	if err := stashClient.AddInsightAnnotations("PROJ", "slug", "a1b2c3", "sast", annotations[:maxAnnotationsPerReport]); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	want := fmt.Sprint([]int{maxAnnotationsPerReport})
+	if fmt.Sprint(requests) != want {
+		t.Fatalf("Want one request of %s but got %v\n", want, requests)
+	}
+}
@@ -0,0 +1,134 @@
+package stash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Merge strategies accepted by MergePullRequest.  An empty strategy uses the
+// repository's default.
+const (
+	MergeNoFastForward         = "no-ff"
+	MergeFastForward           = "ff"
+	MergeFastForwardOnly       = "ff-only"
+	MergeSquash                = "squash"
+	MergeSquashFastForwardOnly = "squash-ff-only"
+	MergeRebaseNoFastForward   = "rebase-no-ff"
+	MergeRebaseFastForwardOnly = "rebase-ff-only"
+)
+
+type (
+	// MergeStatus tells whether a pull request can be merged and, if not, why.
+	MergeStatus struct {
+		CanMerge   bool `json:"canMerge"`
+		Conflicted bool `json:"conflicted"`
+		// Outcome is CLEAN, CONFLICTED or UNKNOWN.
+		Outcome string      `json:"outcome"`
+		Vetoes  []MergeVeto `json:"vetoes"`
+	}
+
+	// MergeVeto is a merge check preventing a merge, such as missing approvals
+	// or a failing build.
+	MergeVeto struct {
+		SummaryMessage  string `json:"summaryMessage"`
+		DetailedMessage string `json:"detailedMessage"`
+	}
+
+	mergeResource struct {
+		Message    string `json:"message,omitempty"`
+		StrategyID string `json:"strategyId,omitempty"`
+	}
+)
+
+// CanMerge reports whether a pull request can be merged, with the vetoes that
+// prevent it.
+func (client Client) CanMerge(projectKey, repositorySlug string, pullRequestID int) (MergeStatus, error) {
+	return client.CanMergeContext(context.Background(), projectKey, repositorySlug, pullRequestID)
+}
+
+// CanMergeContext is like CanMerge but uses ctx for the request.
+func (client Client) CanMergeContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) (MergeStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/merge", client.baseURL.String(), projectKey, repositorySlug, pullRequestID), nil)
+	if err != nil {
+		return MergeStatus{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return MergeStatus{}, err
+	}
+
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return MergeStatus{}, newAPIError(req, responseCode, data, reason)
+	}
+
+	var status MergeStatus
+	err = json.Unmarshal(data, &status)
+	return status, err
+}
+
+// MergePullRequest merges a pull request at version using strategy, one of the
+// Merge constants.  An empty message lets Stash write the commit message.
+// Merges refused by merge checks fail with an error for which IsMergeVetoed is
+// true.
+func (client Client) MergePullRequest(projectKey, repositorySlug string, pullRequestID, version int, strategy, message string) (PullRequest, error) {
+	return client.MergePullRequestContext(context.Background(), projectKey, repositorySlug, pullRequestID, version, strategy, message)
+}
+
+// MergePullRequestContext is like MergePullRequest but uses ctx for the request.
+func (client Client) MergePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID, version int, strategy, message string) (PullRequest, error) {
+	reqBody, err := json.Marshal(mergeResource{Message: message, StrategyID: strategy})
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/merge?version=%d", client.baseURL.String(), projectKey, repositorySlug, pullRequestID, version),
+		bytes.NewBuffer(reqBody),
+	)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-type", "application/json")
+
+	// https://confluence.atlassian.com/cloudkb/xsrf-check-failed-when-calling-cloud-apis-826874382.html
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "Bad request"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusConflict:
+			reason = "Conflict"
+		}
+		return PullRequest{}, newAPIError(req, responseCode, data, reason)
+	}
+
+	var pullRequest PullRequest
+	err = json.Unmarshal(data, &pullRequest)
+	return pullRequest, err
+}
@@ -0,0 +1,125 @@
+package stash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestMergePullRequest(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Want POST but found %s\n", r.Method)
+		}
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/merge" {
+			t.Errorf("Want merge path but got %s\n", r.URL.Path)
+		}
+		if version := r.URL.Query().Get("version"); version != "3" {
+			t.Errorf("Want version 3 but got %s\n", version)
+		}
+		if r.Header.Get("X-Atlassian-Token") != "no-check" {
+			t.Errorf("Want X-Atlassian-Token header value no-check, but got %s\n", r.Header.Get("X-Atlassian-Token"))
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Not expecting error: %v\n", err)
+		}
+		if body["strategyId"] != "squash" || body["message"] != "Release 1.2" {
+			t.Errorf("Want squash strategy and message but got %v\n", body)
+		}
+		fmt.Fprint(w, `{"id": 7, "version": 4, "state": "MERGED", "closed": true}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	pullRequest, err := stashClient.MergePullRequest("PROJ", "slug", 7, 3, MergeSquash, "Release 1.2")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if pullRequest.State != "MERGED" || pullRequest.Version != 4 {
+		t.Fatalf("Want merged pull request at version 4 but got %+v\n", pullRequest)
+	}
+}
+
+func TestMergePullRequestVetoed(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{
+    "errors": [
+        {
+            "context": null,
+            "message": "Merging the pull request has been vetoed.",
+            "exceptionName": "com.atlassian.bitbucket.pull.PullRequestMergeVetoedException",
+            "conflicted": false,
+            "vetoes": [
+                {
+                    "summaryMessage": "Not enough approvals",
+                    "detailedMessage": "You need 2 more approvals before this pull request can be merged."
+                }
+            ]
+        }
+    ]
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	_, err := stashClient.MergePullRequest("PROJ", "slug", 7, 3, "", "")
+	if !IsMergeVetoed(err) {
+		t.Fatalf("Want vetoed merge but got %v\n", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Want *APIError but got %T\n", err)
+	}
+	if vetoes := apiErr.Errors[0].Vetoes; len(vetoes) != 1 || vetoes[0].SummaryMessage != "Not enough approvals" {
+		t.Fatalf("Want the approvals veto but got %+v\n", vetoes)
+	}
+	if IsMergeVetoed(&APIError{StatusCode: http.StatusConflict}) {
+		t.Fatalf("Want a plain conflict not to be a vetoed merge\n")
+	}
+}
+
+func TestCanMerge(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Want GET but found %s\n", r.Method)
+		}
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/merge" {
+			t.Errorf("Want merge path but got %s\n", r.URL.Path)
+		}
+		fmt.Fprint(w, `{
+    "canMerge": false,
+    "conflicted": true,
+    "outcome": "CONFLICTED",
+    "vetoes": [
+        {
+            "summaryMessage": "Build failing",
+            "detailedMessage": "The latest build of the source branch failed."
+        }
+    ]
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	status, err := stashClient.CanMerge("PROJ", "slug", 7)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if status.CanMerge || !status.Conflicted || status.Outcome != "CONFLICTED" {
+		t.Fatalf("Want a conflicted pull request but got %+v\n", status)
+	}
+	if len(status.Vetoes) != 1 || status.Vetoes[0].SummaryMessage != "Build failing" {
+		t.Fatalf("Want the build veto but got %+v\n", status.Vetoes)
+	}
+}
@@ -0,0 +1,185 @@
+package stash
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type (
+	// ClientOption configures a client built by NewClientWithOptions.
+	ClientOption func(*clientConfig) error
+
+	clientConfig struct {
+		auth       Authenticator
+		retry      *RetryPolicy
+		pageLimit  int
+		httpClient *http.Client
+		tlsConfig  *tls.Config
+		proxy      func(*http.Request) (*url.URL, error)
+		timeout    time.Duration
+
+		// transportSet records whether any option touched the transport we build,
+		// so it can be rejected alongside WithHTTPClient.
+		transportSet bool
+	}
+)
+
+// NewClientWithOptions returns a Stash client for baseURL configured by options.
+// Without options it behaves like NewClient with no credentials: certificates are
+// verified against the system roots and requests time out after 30 seconds.
+func NewClientWithOptions(baseURL *url.URL, options ...ClientOption) (Stash, error) {
+	if baseURL == nil {
+		return nil, errors.New("stash: base URL is required")
+	}
+
+	config := clientConfig{timeout: 30 * time.Second}
+	for _, option := range options {
+		if err := option(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	hc := config.httpClient
+	if hc == nil {
+		hc = newHTTPClient(config.tlsConfig, config.proxy, config.timeout)
+	} else if config.transportSet {
+		return nil, errors.New("stash: WithHTTPClient cannot be combined with TLS, proxy or timeout options")
+	}
+
+	return Client{
+		auth:       config.auth,
+		baseURL:    baseURL,
+		httpClient: hc,
+		retry:      config.retry,
+		pageLimit:  config.pageLimit,
+	}, nil
+}
+
+// WithAuthenticator makes the client authenticate every request with auth.
+func WithAuthenticator(auth Authenticator) ClientOption {
+	return func(config *clientConfig) error {
+		if auth == nil {
+			return errors.New("stash: nil Authenticator")
+		}
+		config.auth = auth
+		return nil
+	}
+}
+
+// WithBasicAuth sets the user name and password sent with every request.
+func WithBasicAuth(userName, password string) ClientOption {
+	return WithAuthenticator(BasicAuth{UserName: userName, Password: password})
+}
+
+// WithBearerToken authenticates every request with an HTTP access token.
+func WithBearerToken(token string) ClientOption {
+	return WithAuthenticator(BearerToken(token))
+}
+
+// WithHTTPClient makes the client send its requests through hc, which is used as
+// is.  It cannot be combined with the TLS, proxy and timeout options; configure hc
+// itself instead.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(config *clientConfig) error {
+		if hc == nil {
+			return errors.New("stash: nil http.Client")
+		}
+		config.httpClient = hc
+		return nil
+	}
+}
+
+// WithCABundle trusts the PEM encoded certificates in pemCerts in addition to
+// the system roots, or to the pool given to WithRootCAs, which is left as is.
+func WithCABundle(pemCerts []byte) ClientOption {
+	return func(config *clientConfig) error {
+		tlsConfig := config.tls()
+		var pool *x509.CertPool
+		if tlsConfig.RootCAs != nil {
+			pool = tlsConfig.RootCAs.Clone()
+		} else if system, err := x509.SystemCertPool(); err == nil {
+			pool = system
+		} else {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemCerts) {
+			return errors.New("stash: no certificates found in CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+		return nil
+	}
+}
+
+// WithRootCAs replaces the system roots with pool when verifying the server.
+func WithRootCAs(pool *x509.CertPool) ClientOption {
+	return func(config *clientConfig) error {
+		config.tls().RootCAs = pool
+		return nil
+	}
+}
+
+// WithClientCertificate presents cert to servers that require mutual TLS.
+func WithClientCertificate(cert tls.Certificate) ClientOption {
+	return func(config *clientConfig) error {
+		tlsConfig := config.tls()
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify disables server certificate verification.  It exists for
+// test instances with self-signed certificates and should not be used otherwise.
+func WithInsecureSkipVerify() ClientOption {
+	return func(config *clientConfig) error {
+		config.tls().InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// WithProxy sends every request through the proxy at proxyURL.  By default the
+// proxy is taken from the environment, see http.ProxyFromEnvironment.
+func WithProxy(proxyURL *url.URL) ClientOption {
+	return func(config *clientConfig) error {
+		config.proxy = http.ProxyURL(proxyURL)
+		config.transportSet = true
+		return nil
+	}
+}
+
+// WithTimeout limits the time a single request may take, including reading the
+// response body.  Zero means no timeout.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(config *clientConfig) error {
+		if timeout < 0 {
+			return errors.New("stash: negative timeout")
+		}
+		config.timeout = timeout
+		config.transportSet = true
+		return nil
+	}
+}
+
+func (config *clientConfig) tls() *tls.Config {
+	if config.tlsConfig == nil {
+		config.tlsConfig = &tls.Config{}
+	}
+	config.transportSet = true
+	return config.tlsConfig
+}
+
+// newHTTPClient returns an http.Client whose transport verifies certificates
+// according to tlsConfig, or the system defaults when it is nil.
+func newHTTPClient(tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error), timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	if proxy != nil {
+		transport.Proxy = proxy
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,102 @@
+package stash
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const tagsPage = `{"isLastPage": true, "values": [{"displayId": "v1"}]}`
+
+func TestNewClientVerifiesCertificates(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, tagsPage)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	if _, err := stashClient.GetTags("PRJ", "widge"); err == nil {
+		t.Fatalf("Want certificate verification error but got none\n")
+	}
+}
+
+func TestNewClientWithOptionsRootCAs(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic dTpw" {
+			t.Errorf("Want Basic dTpw but found %s\n", r.Header.Get("Authorization"))
+		}
+		fmt.Fprint(w, tagsPage)
+	}))
+	defer testServer.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(testServer.Certificate())
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient, err := NewClientWithOptions(url, WithBasicAuth("u", "p"), WithRootCAs(pool), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	tags, err := stashClient.GetTags("PRJ", "widge")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, ok := tags["v1"]; !ok {
+		t.Fatalf("Want tag v1 but got %v\n", tags)
+	}
+}
+
+func TestNewClientWithOptionsHTTPClient(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, tagsPage)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient, err := NewClientWithOptions(url, WithHTTPClient(testServer.Client()))
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := stashClient.GetTags("PRJ", "widge"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	if _, err := NewClientWithOptions(url, WithHTTPClient(testServer.Client()), WithInsecureSkipVerify()); err == nil {
+		t.Fatalf("Want error combining WithHTTPClient and TLS options but got none\n")
+	}
+}
+
+func TestWithCABundleLeavesRootCAsAlone(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, tagsPage)
+	}))
+	defer testServer.Close()
+
+	pool := x509.NewCertPool()
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testServer.Certificate().Raw})
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient, err := NewClientWithOptions(url, WithRootCAs(pool), WithCABundle(bundle))
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := stashClient.GetTags("PRJ", "widge"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if !pool.Equal(x509.NewCertPool()) {
+		t.Fatalf("Want the caller's pool unchanged but the CA bundle was added to it\n")
+	}
+}
+
+func TestWithCABundleRejectsGarbage(t *testing.T) {
+	url, _ := url.Parse("https://stash.example.com")
+	if _, err := NewClientWithOptions(url, WithCABundle([]byte("not a certificate"))); err == nil {
+		t.Fatalf("Want error for CA bundle without certificates but got none\n")
+	}
+}
@@ -0,0 +1,179 @@
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type (
+	// PageOptions controls how a PageIterator walks a collection.
+	PageOptions struct {
+		// Limit is the page size asked of the server.  Zero means the client's
+		// default of 25, see WithPageLimit.
+		Limit int
+		// MaxItems stops the iteration after that many items.  Zero means all of them.
+		MaxItems int
+	}
+
+	// PageIterator lazily walks a paged Stash collection, fetching the next page only
+	// once the items of the current one have been consumed.  Stopping early is just
+	// a matter of no longer calling Next.
+	//
+	//	it := client.Paginate(ctx, "/rest/api/1.0/repos", nil, stash.PageOptions{})
+	//	for it.Next() {
+	//		var repo stash.Repository
+	//		if err := it.Decode(&repo); err != nil {
+	//			return err
+	//		}
+	//	}
+	//	if err := it.Err(); err != nil {
+	//		return err
+	//	}
+	PageIterator struct {
+		client  Client
+		ctx     context.Context
+		path    string
+		query   url.Values
+		options PageOptions
+		reason  func(responseCode int) string
+
+		page    Page
+		values  []json.RawMessage
+		current json.RawMessage
+		seen    int
+		started bool
+		err     error
+	}
+
+	rawPage struct {
+		Page
+		Values []json.RawMessage `json:"values"`
+	}
+)
+
+// WithPageLimit sets the page size list methods ask of the server.
+func WithPageLimit(limit int) ClientOption {
+	return func(config *clientConfig) error {
+		if limit < 1 {
+			return fmt.Errorf("stash: page limit must be positive, got %d", limit)
+		}
+		config.pageLimit = limit
+		return nil
+	}
+}
+
+// Paginate returns an iterator over the collection at path, a REST resource below
+// the base URL such as "/rest/api/1.0/repos".  query holds any filters besides
+// start and limit, which the iterator manages.  It is not part of the Stash
+// interface; assert a Stash to Client to use it.
+func (client Client) Paginate(ctx context.Context, path string, query url.Values, options PageOptions) *PageIterator {
+	return client.paginate(ctx, path, query, options, http.StatusText)
+}
+
+// paginate is Paginate for list methods, whose errors carry the reason they
+// give for each response code.
+func (client Client) paginate(ctx context.Context, path string, query url.Values, options PageOptions, reason func(responseCode int) string) *PageIterator {
+	if options.Limit <= 0 {
+		options.Limit = client.pageLimit
+	}
+	if options.Limit <= 0 {
+		options.Limit = stashPageLimit
+	}
+	return &PageIterator{client: client, ctx: ctx, path: path, query: query, options: options, reason: reason}
+}
+
+// Next advances to the next item, fetching a page if needed.  It returns false at
+// the end of the collection, once MaxItems items were returned or on error.
+func (it *PageIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.options.MaxItems > 0 && it.seen >= it.options.MaxItems {
+		return false
+	}
+	for len(it.values) == 0 {
+		if it.started && it.page.IsLastPage {
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+		if len(it.values) == 0 && !it.page.IsLastPage {
+			it.err = fmt.Errorf("stash: empty page at start %d of %s", it.page.Start, it.path)
+			return false
+		}
+	}
+	it.current, it.values = it.values[0], it.values[1:]
+	it.seen++
+	return true
+}
+
+// Decode unmarshals the current item into v.
+func (it *PageIterator) Decode(v interface{}) error {
+	if it.current == nil {
+		return errors.New("stash: Decode called without a successful Next")
+	}
+	return json.Unmarshal(it.current, v)
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+// Page returns the paging information of the page the current item belongs to.
+func (it *PageIterator) Page() Page {
+	return it.page
+}
+
+func (it *PageIterator) fetch() error {
+	start := 0
+	if it.started {
+		if it.page.NextPageStart <= it.page.Start {
+			return fmt.Errorf("stash: page at start %d of %s does not advance", it.page.Start, it.path)
+		}
+		start = it.page.NextPageStart
+	}
+
+	limit := it.options.Limit
+	if it.options.MaxItems > 0 && it.options.MaxItems-it.seen < limit {
+		limit = it.options.MaxItems - it.seen
+	}
+
+	query := url.Values{}
+	for key, values := range it.query {
+		query[key] = values
+	}
+	query.Set("start", strconv.Itoa(start))
+	query.Set("limit", strconv.Itoa(limit))
+
+	req, err := http.NewRequestWithContext(it.ctx, "GET", fmt.Sprintf("%s%s?%s", it.client.baseURL.String(), it.path, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := it.client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+	if responseCode != http.StatusOK {
+		return newAPIError(req, responseCode, data, it.reason(responseCode))
+	}
+
+	var page rawPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return err
+	}
+	it.started = true
+	it.page = page.Page
+	it.page.Start = start
+	it.values = page.Values
+	return nil
+}
@@ -0,0 +1,138 @@
+package stash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pagedTags serves total tags, honoring start and limit like Stash does.
+func pagedTags(total int, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RawQuery)
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		var values []string
+		for i := start; i < start+limit && i < total; i++ {
+			values = append(values, fmt.Sprintf(`{"displayId": "v%d"}`, i))
+		}
+		last := start+limit >= total
+		fmt.Fprintf(w, `{"isLastPage": %v, "start": %d, "limit": %d, "size": %d, "nextPageStart": %d, "values": [%s]}`,
+			last, start, limit, len(values), start+limit, strings.Join(values, ","))
+	}))
+}
+
+func TestGetTagsPaging(t *testing.T) {
+	var requests []string
+	testServer := pagedTags(60, &requests)
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	tags, err := stashClient.GetTags("PRJ", "widge")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(tags) != 60 {
+		t.Fatalf("Want 60 tags but got %d\n", len(tags))
+	}
+	want := []string{"limit=25&start=0", "limit=25&start=25", "limit=25&start=50"}
+	if fmt.Sprint(requests) != fmt.Sprint(want) {
+		t.Fatalf("Want %v but got %v\n", want, requests)
+	}
+}
+
+func TestPaginateMaxItems(t *testing.T) {
+	var requests []string
+	testServer := pagedTags(60, &requests)
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url).(Client)
+	it := stashClient.Paginate(context.Background(), "/rest/api/1.0/projects/PRJ/repos/widge/tags", nil, PageOptions{Limit: 10, MaxItems: 15})
+
+	var got []string
+	for it.Next() {
+		var tag Tag
+		if err := it.Decode(&tag); err != nil {
+			t.Fatalf("Not expecting error: %v\n", err)
+		}
+		got = append(got, tag.DisplayID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(got) != 15 || got[14] != "v14" {
+		t.Fatalf("Want v0 to v14 but got %v\n", got)
+	}
+	want := []string{"limit=10&start=0", "limit=5&start=10"}
+	if fmt.Sprint(requests) != fmt.Sprint(want) {
+		t.Fatalf("Want %v but got %v\n", want, requests)
+	}
+}
+
+func TestPaginateStopsEarly(t *testing.T) {
+	var requests []string
+	testServer := pagedTags(60, &requests)
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url).(Client)
+	it := stashClient.Paginate(context.Background(), "/rest/api/1.0/projects/PRJ/repos/widge/tags", nil, PageOptions{})
+	for it.Next() {
+		var tag Tag
+		if err := it.Decode(&tag); err != nil {
+			t.Fatalf("Not expecting error: %v\n", err)
+		}
+		if tag.DisplayID == "v3" {
+			break
+		}
+	}
+	if len(requests) != 1 {
+		t.Fatalf("Want a single page fetched but got %v\n", requests)
+	}
+}
+
+func TestPaginateRejectsStuckPages(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"isLastPage": false, "nextPageStart": 0, "values": [{"displayId": "v0"}]}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	if _, err := stashClient.GetTags("PRJ", "widge"); err == nil {
+		t.Fatalf("Want error for a page that does not advance but got none\n")
+	}
+}
+
+func TestPagedErrorReasons(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	_, err := stashClient.GetRepositories()
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Reason != "Bad request." {
+		t.Fatalf("Want reason Bad request. but got %v\n", err)
+	}
+	_, err = stashClient.GetComments("PRJ", "widge", "1", "README.md")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Reason != "Cannot get comments due to a validation error." {
+		t.Fatalf("Want the comment validation reason but got %v\n", err)
+	}
+	it := stashClient.(Client).Paginate(context.Background(), "/rest/api/1.0/repos", nil, PageOptions{})
+	if it.Next() {
+		t.Fatalf("Want no items\n")
+	}
+	if apiErr, ok := it.Err().(*APIError); !ok || apiErr.Reason != "Bad Request" {
+		t.Fatalf("Want reason Bad Request but got %v\n", it.Err())
+	}
+}
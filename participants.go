@@ -0,0 +1,185 @@
+package stash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Participant statuses accepted by SetParticipantStatus.
+const (
+	StatusApproved   = "APPROVED"
+	StatusUnapproved = "UNAPPROVED"
+	StatusNeedsWork  = "NEEDS_WORK"
+)
+
+type (
+	// Participant is a user taking part in a pull request, as its author, one of
+	// its reviewers or a participant.
+	Participant struct {
+		User               User   `json:"user"`
+		Role               string `json:"role"`
+		Approved           bool   `json:"approved"`
+		Status             string `json:"status"`
+		LastReviewedCommit string `json:"lastReviewedCommit,omitempty"`
+	}
+
+	participantResource struct {
+		User     User   `json:"user"`
+		Approved bool   `json:"approved"`
+		Status   string `json:"status"`
+	}
+)
+
+// ApprovePullRequest approves a pull request as the current user.
+func (client Client) ApprovePullRequest(projectKey, repositorySlug string, pullRequestID int) (Participant, error) {
+	return client.ApprovePullRequestContext(context.Background(), projectKey, repositorySlug, pullRequestID)
+}
+
+// ApprovePullRequestContext is like ApprovePullRequest but uses ctx for the request.
+func (client Client) ApprovePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) (Participant, error) {
+	return client.approve(ctx, "POST", projectKey, repositorySlug, pullRequestID)
+}
+
+// UnapprovePullRequest withdraws the current user's approval of a pull request.
+func (client Client) UnapprovePullRequest(projectKey, repositorySlug string, pullRequestID int) (Participant, error) {
+	return client.UnapprovePullRequestContext(context.Background(), projectKey, repositorySlug, pullRequestID)
+}
+
+// UnapprovePullRequestContext is like UnapprovePullRequest but uses ctx for the request.
+func (client Client) UnapprovePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) (Participant, error) {
+	return client.approve(ctx, "DELETE", projectKey, repositorySlug, pullRequestID)
+}
+
+// NeedsWorkPullRequest marks a pull request as needing work on behalf of the
+// current user.
+func (client Client) NeedsWorkPullRequest(projectKey, repositorySlug string, pullRequestID int) (Participant, error) {
+	return client.NeedsWorkPullRequestContext(context.Background(), projectKey, repositorySlug, pullRequestID)
+}
+
+// NeedsWorkPullRequestContext is like NeedsWorkPullRequest but uses ctx for all of its requests.
+func (client Client) NeedsWorkPullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) (Participant, error) {
+	userSlug, err := client.currentUserSlug(ctx)
+	if err != nil {
+		return Participant{}, err
+	}
+	return client.SetParticipantStatusContext(ctx, projectKey, repositorySlug, pullRequestID, userSlug, StatusNeedsWork)
+}
+
+// SetParticipantStatus sets the review status of the participant with userSlug
+// to StatusApproved, StatusUnapproved or StatusNeedsWork.  Users not yet taking
+// part in the pull request are added as participants.
+func (client Client) SetParticipantStatus(projectKey, repositorySlug string, pullRequestID int, userSlug, status string) (Participant, error) {
+	return client.SetParticipantStatusContext(context.Background(), projectKey, repositorySlug, pullRequestID, userSlug, status)
+}
+
+// SetParticipantStatusContext is like SetParticipantStatus but uses ctx for the request.
+func (client Client) SetParticipantStatusContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int, userSlug, status string) (Participant, error) {
+	reqBody, err := json.Marshal(participantResource{
+		User:     User{Name: userSlug},
+		Approved: status == StatusApproved,
+		Status:   status,
+	})
+	if err != nil {
+		return Participant{}, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"PUT",
+		fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/participants/%s", client.baseURL.String(), projectKey, repositorySlug, pullRequestID, url.PathEscape(userSlug)),
+		bytes.NewBuffer(reqBody),
+	)
+	if err != nil {
+		return Participant{}, err
+	}
+	req.Header.Set("Content-type", "application/json")
+	return client.participant(req)
+}
+
+func (client Client) approve(ctx context.Context, method, projectKey, repositorySlug string, pullRequestID int) (Participant, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/approve", client.baseURL.String(), projectKey, repositorySlug, pullRequestID), nil)
+	if err != nil {
+		return Participant{}, err
+	}
+	return client.participant(req)
+}
+
+// participant sends a participant status change and decodes the updated
+// participant.
+func (client Client) participant(req *http.Request) (Participant, error) {
+	req.Header.Set("Accept", "application/json")
+
+	// https://confluence.atlassian.com/cloudkb/xsrf-check-failed-when-calling-cloud-apis-826874382.html
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return Participant{}, err
+	}
+
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "Bad request"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusConflict:
+			reason = "Conflict"
+		}
+		return Participant{}, newAPIError(req, responseCode, data, reason)
+	}
+
+	var participant Participant
+	err = json.Unmarshal(data, &participant)
+	return participant, err
+}
+
+// currentUserSlug asks Stash for the slug of the user the client is
+// authenticated as.  Slugs are lowercased and escaped, so a basic auth user name
+// such as John.Doe@corp is only used to look the user up.
+func (client Client) currentUserSlug(ctx context.Context) (string, error) {
+	var userName string
+	if auth, ok := client.auth.(BasicAuth); ok {
+		userName = auth.UserName
+	}
+	if userName == "" {
+		req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/plugins/servlet/applinks/whoami", client.baseURL.String()), nil)
+		if err != nil {
+			return "", err
+		}
+		responseCode, data, err := client.consumeResponse(req)
+		if err != nil {
+			return "", err
+		}
+		if responseCode != http.StatusOK {
+			return "", newAPIError(req, responseCode, data, "Cannot determine the current user")
+		}
+		if userName = strings.TrimSpace(string(data)); userName == "" {
+			return "", errors.New("stash: the client is not authenticated as a user")
+		}
+	}
+
+	it := client.Paginate(ctx, "/rest/api/1.0/users", url.Values{"filter": {userName}}, PageOptions{})
+	for it.Next() {
+		var user User
+		if err := it.Decode(&user); err != nil {
+			return "", err
+		}
+		if strings.EqualFold(user.Name, userName) {
+			return user.Slug, nil
+		}
+	}
+	if err := it.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("stash: no user named %s", userName)
+}
@@ -0,0 +1,144 @@
+package stash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestApprovePullRequest(t *testing.T) {
+	var tests = []struct {
+		approve func(Stash) (Participant, error)
+		method  string
+		status  string
+	}{
+		{
+			approve: func(stashClient Stash) (Participant, error) {
+				return stashClient.ApprovePullRequest("PROJ", "slug", 7)
+			},
+			method: "POST",
+			status: StatusApproved,
+		},
+		{
+			approve: func(stashClient Stash) (Participant, error) {
+				return stashClient.UnapprovePullRequest("PROJ", "slug", 7)
+			},
+			method: "DELETE",
+			status: StatusUnapproved,
+		},
+	}
+
+	for testNumber, test := range tests {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != test.method {
+				t.Errorf("Test %d: want %s but found %s\n", testNumber, test.method, r.Method)
+			}
+			if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/approve" {
+				t.Errorf("Test %d: want approve path but got %s\n", testNumber, r.URL.Path)
+			}
+			if r.Header.Get("X-Atlassian-Token") != "no-check" {
+				t.Errorf("Test %d: want X-Atlassian-Token header value no-check, but got %s\n", testNumber, r.Header.Get("X-Atlassian-Token"))
+			}
+			fmt.Fprintf(w, `{"user": {"name": "u", "slug": "u"}, "role": "REVIEWER", "approved": %v, "status": "%s"}`, test.status == StatusApproved, test.status)
+		}))
+		defer testServer.Close()
+
+		url, _ := url.Parse(testServer.URL)
+		participant, err := test.approve(NewClient("u", "p", url))
+		if err != nil {
+			t.Fatalf("Test %d: not expecting error: %v\n", testNumber, err)
+		}
+		if participant.Status != test.status || participant.User.Slug != "u" {
+			t.Fatalf("Test %d: want %s for u but got %+v\n", testNumber, test.status, participant)
+		}
+	}
+}
+
+func TestSetParticipantStatus(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			t.Errorf("Want PUT but found %s\n", r.Method)
+		}
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/participants/bob" {
+			t.Errorf("Want participants path but got %s\n", r.URL.Path)
+		}
+		var body participantResource
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Not expecting error: %v\n", err)
+		}
+		if body.User.Name != "bob" || body.Status != StatusNeedsWork || body.Approved {
+			t.Errorf("Want bob needing work but got %+v\n", body)
+		}
+		fmt.Fprint(w, `{"user": {"name": "bob", "slug": "bob"}, "role": "REVIEWER", "approved": false, "status": "NEEDS_WORK"}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	participant, err := stashClient.SetParticipantStatus("PROJ", "slug", 7, "bob", StatusNeedsWork)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if participant.Status != StatusNeedsWork || participant.Role != "REVIEWER" {
+		t.Fatalf("Want a reviewer needing work but got %+v\n", participant)
+	}
+}
+
+func TestNeedsWorkPullRequestWithToken(t *testing.T) {
+	var paths []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/plugins/servlet/applinks/whoami":
+			fmt.Fprint(w, "ci-bot\n")
+			return
+		case "/rest/api/1.0/users":
+			fmt.Fprint(w, `{"isLastPage": true, "values": [{"name": "ci-bot", "slug": "ci-bot"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"user": {"name": "ci-bot", "slug": "ci-bot"}, "role": "PARTICIPANT", "status": "NEEDS_WORK"}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient, _ := NewClientWithOptions(url, WithBearerToken("t0ken"))
+	if _, err := stashClient.NeedsWorkPullRequest("PROJ", "slug", 7); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	want := "[/plugins/servlet/applinks/whoami /rest/api/1.0/users /rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/participants/ci-bot]"
+	if fmt.Sprint(paths) != want {
+		t.Fatalf("Want %s but got %v\n", want, paths)
+	}
+}
+
+func TestNeedsWorkPullRequestResolvesSlug(t *testing.T) {
+	var paths []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/rest/api/1.0/users" {
+			if r.URL.Query().Get("filter") != "John.Doe@corp" {
+				t.Errorf("Want filter=John.Doe@corp but got %s\n", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"isLastPage": true, "values": [
+    {"name": "John.Doe@corp.example", "slug": "john.doe_corp.example"},
+    {"name": "john.doe@corp", "slug": "john.doe_corp"}
+]}`)
+			return
+		}
+		fmt.Fprint(w, `{"user": {"name": "john.doe@corp", "slug": "john.doe_corp"}, "role": "REVIEWER", "status": "NEEDS_WORK"}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("John.Doe@corp", "p", url)
+	if _, err := stashClient.NeedsWorkPullRequest("PROJ", "slug", 7); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	want := "[/rest/api/1.0/users /rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/participants/john.doe_corp]"
+	if fmt.Sprint(paths) != want {
+		t.Fatalf("Want %s but got %v\n", want, paths)
+	}
+}
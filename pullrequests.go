@@ -0,0 +1,220 @@
+package stash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type (
+	// PullRequestState filters pull requests by state.
+	PullRequestState string
+
+	// PullRequestDirection tells whether PullRequestOptions.At is the target
+	// or the source branch.
+	PullRequestDirection string
+
+	// PullRequestOrder sorts pull requests by creation.
+	PullRequestOrder string
+
+	versionResource struct {
+		Version int `json:"version"`
+	}
+)
+
+// Pull request states.
+const (
+	PullRequestOpen     PullRequestState = "OPEN"
+	PullRequestDeclined PullRequestState = "DECLINED"
+	PullRequestMerged   PullRequestState = "MERGED"
+	PullRequestAll      PullRequestState = "ALL"
+)
+
+// Pull request directions.
+const (
+	DirectionIncoming PullRequestDirection = "INCOMING"
+	DirectionOutgoing PullRequestDirection = "OUTGOING"
+)
+
+// Pull request orders.
+const (
+	OrderNewest PullRequestOrder = "NEWEST"
+	OrderOldest PullRequestOrder = "OLDEST"
+)
+
+// PullRequestOptions filter and order the pull requests of a repository.  The
+// zero value lists open pull requests the way Stash does by default.
+type PullRequestOptions struct {
+	State PullRequestState
+	// At is a branch, such as refs/heads/master, the pull requests target or,
+	// with DirectionOutgoing, come from.
+	At         string
+	Direction  PullRequestDirection
+	Order      PullRequestOrder
+	FilterText string
+	// Author and Reviewer are user names, such as those in User.Name.
+	Author   string
+	Reviewer string
+	// WithoutAttributes and WithoutProperties leave out the attributes and the
+	// properties, such as task counts, which speeds up large listings.
+	WithoutAttributes bool
+	WithoutProperties bool
+}
+
+func (options PullRequestOptions) query() url.Values {
+	query := url.Values{}
+	if options.State != "" {
+		query.Set("state", string(options.State))
+	}
+	if options.At != "" {
+		query.Set("at", options.At)
+	}
+	if options.Direction != "" {
+		query.Set("direction", string(options.Direction))
+	}
+	if options.Order != "" {
+		query.Set("order", string(options.Order))
+	}
+	if options.FilterText != "" {
+		query.Set("filterText", options.FilterText)
+	}
+	participants := 0
+	for _, participant := range []struct{ role, user string }{{"AUTHOR", options.Author}, {"REVIEWER", options.Reviewer}} {
+		if participant.user != "" {
+			participants++
+			query.Set("role."+strconv.Itoa(participants), participant.role)
+			query.Set("username."+strconv.Itoa(participants), participant.user)
+		}
+	}
+	if options.WithoutAttributes {
+		query.Set("withAttributes", "false")
+	}
+	if options.WithoutProperties {
+		query.Set("withProperties", "false")
+	}
+	return query
+}
+
+// GetPullRequestsWithOptions returns the pull requests of a repository matching
+// options.
+func (client Client) GetPullRequestsWithOptions(projectKey, repositorySlug string, options PullRequestOptions) ([]PullRequest, error) {
+	return client.GetPullRequestsWithOptionsContext(context.Background(), projectKey, repositorySlug, options)
+}
+
+// GetPullRequestsWithOptionsContext is like GetPullRequestsWithOptions but uses ctx for every page it requests.
+func (client Client) GetPullRequestsWithOptionsContext(ctx context.Context, projectKey, repositorySlug string, options PullRequestOptions) ([]PullRequest, error) {
+	pullRequests := make([]PullRequest, 0)
+	it := client.paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests", projectKey, repositorySlug), options.query(), PageOptions{}, func(responseCode int) string {
+		switch {
+		case responseCode == http.StatusBadRequest:
+			return "Bad request."
+		}
+		return "unhandled reason"
+	})
+	for it.Next() {
+		var pr PullRequest
+		if err := it.Decode(&pr); err != nil {
+			return nil, err
+		}
+		pullRequests = append(pullRequests, pr)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return pullRequests, nil
+}
+
+// ReopenPullRequest reopens a declined pull request at version.  An out of date
+// version fails with an error for which IsStaleVersion is true.
+func (client Client) ReopenPullRequest(projectKey, repositorySlug string, pullRequestID, version int) (PullRequest, error) {
+	return client.ReopenPullRequestContext(context.Background(), projectKey, repositorySlug, pullRequestID, version)
+}
+
+// ReopenPullRequestContext is like ReopenPullRequest but uses ctx for the request.
+func (client Client) ReopenPullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID, version int) (PullRequest, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/reopen?version=%d", client.baseURL.String(), projectKey, repositorySlug, pullRequestID, version),
+		nil,
+	)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// https://confluence.atlassian.com/cloudkb/xsrf-check-failed-when-calling-cloud-apis-826874382.html
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusConflict:
+			reason = "Conflict"
+		}
+		return PullRequest{}, newAPIError(req, responseCode, data, reason)
+	}
+
+	var pullRequest PullRequest
+	err = json.Unmarshal(data, &pullRequest)
+	return pullRequest, err
+}
+
+// DeletePullRequest permanently deletes a pull request at version, with its
+// comments and tasks.  An out of date version fails with an error for which
+// IsStaleVersion is true.
+func (client Client) DeletePullRequest(projectKey, repositorySlug string, pullRequestID, version int) error {
+	return client.DeletePullRequestContext(context.Background(), projectKey, repositorySlug, pullRequestID, version)
+}
+
+// DeletePullRequestContext is like DeletePullRequest but uses ctx for the request.
+func (client Client) DeletePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID, version int) error {
+	reqBody, err := json.Marshal(versionResource{Version: version})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"DELETE",
+		fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d", client.baseURL.String(), projectKey, repositorySlug, pullRequestID),
+		bytes.NewBuffer(reqBody),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-type", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+
+	if responseCode != http.StatusNoContent {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusConflict:
+			reason = "Conflict"
+		}
+		return newAPIError(req, responseCode, data, reason)
+	}
+	return nil
+}
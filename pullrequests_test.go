@@ -0,0 +1,168 @@
+package stash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGetPullRequestsWithOptions(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests" {
+			t.Errorf("Want pull requests path but got %s\n", r.URL.Path)
+		}
+		want := url.Values{
+			"state":          {"MERGED"},
+			"at":             {"refs/heads/master"},
+			"direction":      {"INCOMING"},
+			"order":          {"OLDEST"},
+			"filterText":     {"release"},
+			"role.1":         {"AUTHOR"},
+			"username.1":     {"alice"},
+			"role.2":         {"REVIEWER"},
+			"username.2":     {"bob"},
+			"withProperties": {"false"},
+		}
+		query := r.URL.Query()
+		query.Del("start")
+		query.Del("limit")
+		if query.Encode() != want.Encode() {
+			t.Errorf("Want %s but got %s\n", want.Encode(), query.Encode())
+		}
+		fmt.Fprint(w, `{"isLastPage": true, "values": [{"id": 3, "state": "MERGED"}, {"id": 5, "state": "MERGED"}]}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	pullRequests, err := stashClient.GetPullRequestsWithOptions("PROJ", "slug", PullRequestOptions{
+		State:             PullRequestMerged,
+		At:                "refs/heads/master",
+		Direction:         DirectionIncoming,
+		Order:             OrderOldest,
+		FilterText:        "release",
+		Author:            "alice",
+		Reviewer:          "bob",
+		WithoutProperties: true,
+	})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(pullRequests) != 2 || pullRequests[0].ID != 3 {
+		t.Fatalf("Want pull requests 3 and 5 but got %+v\n", pullRequests)
+	}
+}
+
+func TestCreateCrossRepositoryPullRequest(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests" {
+			t.Errorf("Want the target repository's pull requests path but got %s\n", r.URL.Path)
+		}
+		var body struct {
+			FromRef PullRequestRef `json:"fromRef"`
+			ToRef   PullRequestRef `json:"toRef"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Not expecting error: %v\n", err)
+		}
+		if body.FromRef.Repository.Project.Key != "~ALICE" || body.FromRef.Repository.Slug != "slug" || body.FromRef.Id != "refs/heads/fix" {
+			t.Errorf("Want fix of ~ALICE/slug but got %+v\n", body.FromRef)
+		}
+		if body.ToRef.Repository.Project.Key != "PROJ" || body.ToRef.Id != "refs/heads/master" {
+			t.Errorf("Want master of PROJ/slug but got %+v\n", body.ToRef)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{
+    "id": 9,
+    "state": "OPEN",
+    "fromRef": {
+        "id": "refs/heads/fix",
+        "displayId": "fix",
+        "latestCommit": "a1b2",
+        "repository": {"slug": "slug", "name": "slug", "project": {"key": "~ALICE"}}
+    },
+    "toRef": {
+        "id": "refs/heads/master",
+        "displayId": "master",
+        "latestCommit": "c3d4",
+        "repository": {"slug": "slug", "name": "slug", "project": {"key": "PROJ"}}
+    }
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	from := PullRequestRef{Id: "refs/heads/fix", Repository: PullRequestRepository{Slug: "slug", Project: PullRequestProject{Key: "~ALICE"}}}
+	to := PullRequestRef{Id: "refs/heads/master", Repository: PullRequestRepository{Slug: "slug", Project: PullRequestProject{Key: "PROJ"}}}
+	pullRequest, err := stashClient.CreateCrossRepositoryPullRequest("Fix", "", from, to, nil)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if pullRequest.FromRef.Repository.Project.Key != "~ALICE" || pullRequest.FromRef.LatestCommit != "a1b2" || pullRequest.ToRef.ID != "refs/heads/master" {
+		t.Fatalf("Want a pull request from ~ALICE into PROJ but got %+v\n", pullRequest)
+	}
+}
+
+func TestReopenPullRequest(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Want POST but found %s\n", r.Method)
+		}
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/reopen" {
+			t.Errorf("Want reopen path but got %s\n", r.URL.Path)
+		}
+		if version := r.URL.Query().Get("version"); version != "2" {
+			t.Errorf("Want version 2 but got %s\n", version)
+		}
+		if r.Header.Get("X-Atlassian-Token") != "no-check" {
+			t.Errorf("Want X-Atlassian-Token header value no-check, but got %s\n", r.Header.Get("X-Atlassian-Token"))
+		}
+		fmt.Fprint(w, `{"id": 7, "version": 3, "state": "OPEN", "open": true}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	pullRequest, err := stashClient.ReopenPullRequest("PROJ", "slug", 7, 2)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if pullRequest.State != "OPEN" || pullRequest.Version != 3 {
+		t.Fatalf("Want open pull request at version 3 but got %+v\n", pullRequest)
+	}
+}
+
+func TestDeletePullRequest(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Errorf("Want DELETE but found %s\n", r.Method)
+		}
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7" {
+			t.Errorf("Want pull request path but got %s\n", r.URL.Path)
+		}
+		var body versionResource
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Not expecting error: %v\n", err)
+		}
+		if body.Version != 2 {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprintf(w, `{"errors": [{"message": "out of date", "exceptionName": "com.atlassian.bitbucket.pull.PullRequestOutOfDateException", "currentVersion": 2, "expectedVersion": %d}]}`, body.Version)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	if err := stashClient.DeletePullRequest("PROJ", "slug", 7, 1); !IsStaleVersion(err) {
+		t.Fatalf("Want stale version error but got %v\n", err)
+	}
+	if err := stashClient.DeletePullRequest("PROJ", "slug", 7, 2); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
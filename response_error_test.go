@@ -2,42 +2,109 @@ package stash
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 )
 
 func TestRepositoryNotExists(t *testing.T) {
 	if IsRepositoryExists(nil) {
-		t.Fatalf("nil an errorResponse type")
+		t.Fatalf("nil an APIError")
 	}
 
 	if IsRepositoryExists(errors.New("foo")) {
-		t.Fatalf("Not an errorResponse type")
+		t.Fatalf("Not an APIError")
 	}
 
-	if !IsRepositoryExists(errorResponse{StatusCode: http.StatusConflict}) {
-		t.Fatalf("Want errorResponse.409")
+	if !IsRepositoryExists(&APIError{StatusCode: http.StatusConflict}) {
+		t.Fatalf("Want APIError.409")
 	}
 
-	if IsRepositoryExists(errorResponse{StatusCode: http.StatusNotFound}) {
-		t.Fatalf("Want errorResponse.409")
+	if IsRepositoryExists(&APIError{StatusCode: http.StatusNotFound}) {
+		t.Fatalf("Want APIError.409")
 	}
 }
 
 func TestRepositoryNotFound(t *testing.T) {
 	if IsRepositoryNotFound(nil) {
-		t.Fatalf("nil not an errorResponse type")
+		t.Fatalf("nil not an APIError")
 	}
 
 	if IsRepositoryExists(errors.New("foo")) {
-		t.Fatalf("Not an errorResponse type")
+		t.Fatalf("Not an APIError")
 	}
 
-	if !IsRepositoryNotFound(errorResponse{StatusCode: http.StatusNotFound}) {
-		t.Fatalf("Want errorResponse.404")
+	if !IsRepositoryNotFound(&APIError{StatusCode: http.StatusNotFound}) {
+		t.Fatalf("Want APIError.404")
 	}
 
-	if IsRepositoryNotFound(errorResponse{StatusCode: http.StatusConflict}) {
-		t.Fatalf("Want errorResponse.404")
+	if IsRepositoryNotFound(&APIError{StatusCode: http.StatusConflict}) {
+		t.Fatalf("Want APIError.404")
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-type", "application/json;charset=UTF-8")
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{
+    "errors": [
+        {
+            "context": null,
+            "message": "You are attempting to modify a pull request based on out-of-date information.",
+            "exceptionName": "com.atlassian.bitbucket.pull.PullRequestOutOfDateException",
+            "currentVersion": 3,
+            "expectedVersion": 2
+        }
+    ]
+}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	_, err := stashClient.UpdatePullRequest("PROJ", "slug", "1", 2, "title", "", "", nil)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Want *APIError but got %T\n", err)
+	}
+	if apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("Want 409 but got %d\n", apiErr.StatusCode)
+	}
+	if apiErr.Method != "PUT" {
+		t.Fatalf("Want PUT but got %s\n", apiErr.Method)
+	}
+	if apiErr.URL != testServer.URL+"/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/1" {
+		t.Fatalf("Want pull request URL but got %s\n", apiErr.URL)
+	}
+	if len(apiErr.Errors) != 1 || *apiErr.Errors[0].CurrentVersion != 3 {
+		t.Fatalf("Want one error with current version 3 but got %+v\n", apiErr.Errors)
+	}
+	if !IsConflict(err) || !IsStaleVersion(err) {
+		t.Fatalf("Want conflict with stale version\n")
+	}
+	if IsNotFound(err) || IsUnauthorized(err) {
+		t.Fatalf("Not expecting 404 or 401\n")
+	}
+}
+
+func TestAPIErrorWithoutPayload(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "<html>Not Found</html>")
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	_, err := stashClient.GetRawFile("PROJ", "slug", "README.md", "master")
+	if !IsNotFound(err) {
+		t.Fatalf("Want not found but got %v\n", err)
+	}
+	if IsStaleVersion(err) {
+		t.Fatalf("Not expecting stale version\n")
 	}
 }
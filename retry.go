@@ -0,0 +1,169 @@
+package stash
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides which failed requests a client sends again and how long it
+// waits in between.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a request is sent, including the first.
+	// Values below 1 disable retries.
+	MaxAttempts int
+
+	// MinBackoff is the wait before the first retry.  It doubles with every further
+	// attempt, up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Jitter shortens every backoff by a random fraction of up to Jitter, so that
+	// many clients failing together don't retry in lockstep.  Between 0 and 1.
+	Jitter float64
+
+	// RetryableStatus lists the response codes worth sending a request again for.
+	// A Retry-After header on such a response replaces the computed backoff.
+	RetryableStatus []int
+
+	// MaxRetryAfter is the longest Retry-After a client waits for.  A response
+	// asking for longer is returned instead of retried.  Zero means no limit.
+	MaxRetryAfter time.Duration
+
+	// RetryNonIdempotent allows POST and PATCH requests to be retried like any
+	// other.  By default they are only retried on 429 Too Many Requests, which the
+	// server returns before doing any work, so that a request which timed out or
+	// failed half way is never applied twice.
+	RetryNonIdempotent bool
+}
+
+var (
+	// DefaultRetryPolicy is used by clients that weren't given a RetryPolicy.
+	DefaultRetryPolicy = RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  100 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		Jitter:      0.2,
+		RetryableStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		MaxRetryAfter: time.Minute,
+	}
+
+	// NoRetry sends every request exactly once.
+	NoRetry = RetryPolicy{MaxAttempts: 1}
+)
+
+// WithRetryPolicy replaces DefaultRetryPolicy for all requests of the client.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(config *clientConfig) error {
+		config.retry = &policy
+		return nil
+	}
+}
+
+// retry reports whether a request with the given method that failed with err or
+// statusCode on its attempt'th try should be sent again, and after how long.
+func (policy RetryPolicy) retry(method string, attempt int, statusCode int, header http.Header, err error) (time.Duration, bool) {
+	if attempt >= policy.MaxAttempts {
+		return 0, false
+	}
+
+	idempotent := policy.RetryNonIdempotent || (method != http.MethodPost && method != http.MethodPatch)
+	if err != nil {
+		return policy.backoff(attempt), idempotent
+	}
+
+	if !policy.retryable(statusCode) {
+		return 0, false
+	}
+	if !idempotent && statusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if delay, ok := retryAfter(header); ok {
+		if policy.MaxRetryAfter > 0 && delay > policy.MaxRetryAfter {
+			return 0, false
+		}
+		return delay, true
+	}
+	return policy.backoff(attempt), true
+}
+
+func (policy RetryPolicy) retryable(statusCode int) bool {
+	for _, code := range policy.RetryableStatus {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait after the attempt'th failed try.
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.MinBackoff
+	for i := 1; i < attempt && (policy.MaxBackoff <= 0 || delay < policy.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	if policy.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * policy.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as a date.
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// rewind returns a copy of req that can be sent again, or false if its body can't
+// be replayed.
+func rewind(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req.Clone(req.Context()), true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, true
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package stash
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var fastRetry = RetryPolicy{
+	MaxAttempts:     3,
+	MinBackoff:      time.Millisecond,
+	MaxBackoff:      time.Millisecond,
+	RetryableStatus: DefaultRetryPolicy.RetryableStatus,
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var tests = []struct {
+		method       string
+		statusCodes  []int
+		wantRequests int
+		wantErr      bool
+	}{
+		// transient server errors are retried until success
+		{method: "GET", statusCodes: []int{503, 500, 200}, wantRequests: 3},
+		// but not beyond MaxAttempts
+		{method: "GET", statusCodes: []int{502, 502, 502, 200}, wantRequests: 3, wantErr: true},
+		// client errors are final
+		{method: "GET", statusCodes: []int{404, 200}, wantRequests: 1, wantErr: true},
+		{method: "DELETE", statusCodes: []int{400, 204}, wantRequests: 1, wantErr: true},
+		// POSTs are retried when rate limited
+		{method: "POST", statusCodes: []int{429, 201}, wantRequests: 2},
+		// and never on errors that may have happened after the work was done
+		{method: "POST", statusCodes: []int{500, 201}, wantRequests: 1, wantErr: true},
+	}
+
+	for testNumber, test := range tests {
+		requests := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != test.method {
+				t.Errorf("Test %d: want %s but found %s\n", testNumber, test.method, r.Method)
+			}
+			statusCode := test.statusCodes[requests]
+			requests++
+			w.WriteHeader(statusCode)
+			if statusCode < 300 {
+				w.Write([]byte(`{"isLastPage": true}`))
+			}
+		}))
+
+		url, _ := url.Parse(testServer.URL)
+		stashClient, _ := NewClientWithOptions(url, WithBasicAuth("u", "p"), WithRetryPolicy(fastRetry))
+
+		var err error
+		switch test.method {
+		case "GET":
+			_, err = stashClient.GetTags("PROJ", "slug")
+		case "DELETE":
+			err = stashClient.DeleteBranch("PROJ", "slug", "feature/a")
+		case "POST":
+			_, err = stashClient.CreateComment("PROJ", "slug", "1", "build passing")
+		}
+		testServer.Close()
+
+		if requests != test.wantRequests {
+			t.Errorf("Test %d: want %d requests but got %d\n", testNumber, test.wantRequests, requests)
+		}
+		if test.wantErr != (err != nil) {
+			t.Errorf("Test %d: want error %v but got %v\n", testNumber, test.wantErr, err)
+		}
+	}
+}
+
+func TestRetryPolicyHonorsRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "7")
+
+	delay, ok := fastRetry.retry("POST", 1, http.StatusTooManyRequests, header, nil)
+	if !ok {
+		t.Fatalf("Want retry on 429\n")
+	}
+	if delay != 7*time.Second {
+		t.Fatalf("Want 7s but got %v\n", delay)
+	}
+
+	header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	if delay, _ := fastRetry.retry("GET", 1, http.StatusServiceUnavailable, header, nil); delay != 0 {
+		t.Fatalf("Want 0 for a date in the past but got %v\n", delay)
+	}
+}
+
+func TestRetryPolicyMaxRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "86400")
+	if _, ok := DefaultRetryPolicy.retry("GET", 1, http.StatusServiceUnavailable, header, nil); ok {
+		t.Fatalf("Want no retry when asked to wait a day\n")
+	}
+	header.Set("Retry-After", time.Now().Add(24*time.Hour).UTC().Format(http.TimeFormat))
+	if _, ok := DefaultRetryPolicy.retry("GET", 1, http.StatusServiceUnavailable, header, nil); ok {
+		t.Fatalf("Want no retry for a date a day away\n")
+	}
+
+	requests := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	start := time.Now()
+	_, err := stashClient.GetTags("PROJ", "slug")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Want 429 error but got %v\n", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Want the 429 returned right away but took %v\n", elapsed)
+	}
+	if requests != 1 {
+		t.Fatalf("Want 1 request but got %d\n", requests)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := policy.backoff(attempt + 1); got != want {
+			t.Errorf("Attempt %d: want %v but got %v\n", attempt+1, want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(1); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("Want jittered backoff between 500ms and 1s but got %v\n", got)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package stash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Ref matcher types of default reviewer conditions.
+const (
+	MatcherBranch        = "BRANCH"
+	MatcherPattern       = "PATTERN"
+	MatcherModelBranch   = "MODEL_BRANCH"
+	MatcherModelCategory = "MODEL_CATEGORY"
+	MatcherAnyRef        = "ANY_REF"
+)
+
+type (
+	// DefaultReviewerCondition adds Reviewers to pull requests whose source and
+	// target refs match SourceRefMatcher and TargetRefMatcher.
+	DefaultReviewerCondition struct {
+		ID                int        `json:"id"`
+		Scope             Scope      `json:"scope"`
+		SourceRefMatcher  RefMatcher `json:"sourceRefMatcher"`
+		TargetRefMatcher  RefMatcher `json:"targetRefMatcher"`
+		Reviewers         []User     `json:"reviewers"`
+		RequiredApprovals int        `json:"requiredApprovals"`
+	}
+
+	// Scope tells whether a condition belongs to a PROJECT or a REPOSITORY,
+	// identified by ResourceID.
+	Scope struct {
+		Type       string `json:"type"`
+		ResourceID int    `json:"resourceId"`
+	}
+
+	// RefMatcher selects refs: a branch ID, a pattern, a branching model branch
+	// or category, or any ref.
+	RefMatcher struct {
+		ID        string         `json:"id"`
+		DisplayID string         `json:"displayId"`
+		Type      RefMatcherType `json:"type"`
+		Active    bool           `json:"active"`
+	}
+
+	// RefMatcherType has one of the Matcher constants as ID.
+	RefMatcherType struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+)
+
+// GetProjectDefaultReviewerConditions returns the default reviewer conditions
+// of a project, which apply to all its repositories.
+func (client Client) GetProjectDefaultReviewerConditions(projectKey string) ([]DefaultReviewerCondition, error) {
+	return client.GetProjectDefaultReviewerConditionsContext(context.Background(), projectKey)
+}
+
+// GetProjectDefaultReviewerConditionsContext is like GetProjectDefaultReviewerConditions but uses ctx for the request.
+func (client Client) GetProjectDefaultReviewerConditionsContext(ctx context.Context, projectKey string) ([]DefaultReviewerCondition, error) {
+	return client.getDefaultReviewerConditions(ctx, fmt.Sprintf("%s/rest/default-reviewers/1.0/projects/%s/conditions", client.baseURL.String(), projectKey))
+}
+
+// GetDefaultReviewerConditions returns the default reviewer conditions of a
+// repository, including those inherited from its project.
+func (client Client) GetDefaultReviewerConditions(projectKey, repositorySlug string) ([]DefaultReviewerCondition, error) {
+	return client.GetDefaultReviewerConditionsContext(context.Background(), projectKey, repositorySlug)
+}
+
+// GetDefaultReviewerConditionsContext is like GetDefaultReviewerConditions but uses ctx for the request.
+func (client Client) GetDefaultReviewerConditionsContext(ctx context.Context, projectKey, repositorySlug string) ([]DefaultReviewerCondition, error) {
+	return client.getDefaultReviewerConditions(ctx, fmt.Sprintf("%s/rest/default-reviewers/1.0/projects/%s/repos/%s/conditions", client.baseURL.String(), projectKey, repositorySlug))
+}
+
+// GetDefaultReviewers returns the reviewers the default reviewer conditions of
+// the target repository add to a pull request from one ref to another.
+func (client Client) GetDefaultReviewers(from, to PullRequestRef) ([]User, error) {
+	return client.GetDefaultReviewersContext(context.Background(), from, to)
+}
+
+// GetDefaultReviewersContext is like GetDefaultReviewers but uses ctx for all of its requests.
+func (client Client) GetDefaultReviewersContext(ctx context.Context, from, to PullRequestRef) ([]User, error) {
+	target, err := client.GetRepositoryContext(ctx, to.Repository.Project.Key, to.Repository.Slug)
+	if err != nil {
+		return nil, err
+	}
+	source := target
+	if from.Repository.Project.Key != to.Repository.Project.Key || from.Repository.Slug != to.Repository.Slug {
+		if source, err = client.GetRepositoryContext(ctx, from.Repository.Project.Key, from.Repository.Slug); err != nil {
+			return nil, err
+		}
+	}
+
+	query := url.Values{}
+	query.Set("sourceRepoId", strconv.Itoa(source.ID))
+	query.Set("targetRepoId", strconv.Itoa(target.ID))
+	query.Set("sourceRefId", from.Id)
+	query.Set("targetRefId", to.Id)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/default-reviewers/1.0/projects/%s/repos/%s/reviewers?%s", client.baseURL.String(), to.Repository.Project.Key, to.Repository.Slug, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "Bad request"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return nil, newAPIError(req, responseCode, data, reason)
+	}
+
+	var users []User
+	err = json.Unmarshal(data, &users)
+	return users, err
+}
+
+// CreatePullRequestWithDefaultReviewers is like CreateCrossRepositoryPullRequest
+// but also asks the default reviewers, after those named in reviewers.  Each
+// reviewer is asked once; user names are compared ignoring case, as Stash does.
+func (client Client) CreatePullRequestWithDefaultReviewers(title, description string, from, to PullRequestRef, reviewers []string) (PullRequest, error) {
+	return client.CreatePullRequestWithDefaultReviewersContext(context.Background(), title, description, from, to, reviewers)
+}
+
+// CreatePullRequestWithDefaultReviewersContext is like CreatePullRequestWithDefaultReviewers but uses ctx for all of its requests.
+func (client Client) CreatePullRequestWithDefaultReviewersContext(ctx context.Context, title, description string, from, to PullRequestRef, reviewers []string) (PullRequest, error) {
+	defaults, err := client.GetDefaultReviewersContext(ctx, from, to)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	ask := func(name string) {
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+	for _, name := range reviewers {
+		ask(name)
+	}
+	for _, user := range defaults {
+		ask(user.Name)
+	}
+	return client.CreateCrossRepositoryPullRequestContext(ctx, title, description, from, to, names)
+}
+
+// NewPullRequestRef returns the ref, such as refs/heads/master, of a repository
+// for CreateCrossRepositoryPullRequest and GetDefaultReviewers.
+func NewPullRequestRef(projectKey, repositorySlug, ref string) PullRequestRef {
+	return PullRequestRef{
+		Id: ref,
+		Repository: PullRequestRepository{
+			Slug: repositorySlug,
+			Project: PullRequestProject{
+				Key: projectKey,
+			},
+		},
+	}
+}
+
+func (client Client) getDefaultReviewerConditions(ctx context.Context, u string) ([]DefaultReviewerCondition, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return nil, newAPIError(req, responseCode, data, reason)
+	}
+
+	var conditions []DefaultReviewerCondition
+	err = json.Unmarshal(data, &conditions)
+	return conditions, err
+}
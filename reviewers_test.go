@@ -0,0 +1,95 @@
+package stash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGetDefaultReviewerConditions(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/default-reviewers/1.0/projects/PROJ/repos/slug/conditions" {
+			t.Errorf("Want conditions path but got %s\n", r.URL.Path)
+		}
+		fmt.Fprint(w, `[
+    {
+        "id": 3,
+        "scope": {"type": "REPOSITORY", "resourceId": 12},
+        "sourceRefMatcher": {"id": "ANY_REF_MATCHER_ID", "displayId": "ANY_REF_MATCHER_ID", "type": {"id": "ANY_REF", "name": "Any branch"}, "active": true},
+        "targetRefMatcher": {"id": "refs/heads/master", "displayId": "master", "type": {"id": "BRANCH", "name": "Branch"}, "active": true},
+        "reviewers": [{"name": "bob", "slug": "bob"}, {"name": "carol", "slug": "carol"}],
+        "requiredApprovals": 1
+    }
+]`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	conditions, err := stashClient.GetDefaultReviewerConditions("PROJ", "slug")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(conditions) != 1 {
+		t.Fatalf("Want 1 condition but got %d\n", len(conditions))
+	}
+	condition := conditions[0]
+	if condition.Scope.Type != "REPOSITORY" || condition.Scope.ResourceID != 12 || condition.RequiredApprovals != 1 {
+		t.Fatalf("Want a repository condition needing 1 approval but got %+v\n", condition)
+	}
+	if condition.SourceRefMatcher.Type.ID != MatcherAnyRef || condition.TargetRefMatcher.ID != "refs/heads/master" || len(condition.Reviewers) != 2 {
+		t.Fatalf("Want bob and carol for anything into master but got %+v\n", condition)
+	}
+}
+
+func TestCreatePullRequestWithDefaultReviewers(t *testing.T) {
+	var paths []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/rest/api/1.0/projects/PROJ/repos/slug":
+			fmt.Fprint(w, `{"id": 12, "slug": "slug", "project": {"key": "PROJ"}}`)
+		case "/rest/api/1.0/projects/~ALICE/repos/slug":
+			fmt.Fprint(w, `{"id": 40, "slug": "slug", "project": {"key": "~ALICE"}}`)
+		case "/rest/default-reviewers/1.0/projects/PROJ/repos/slug/reviewers":
+			want := "sourceRefId=refs%2Fheads%2Ffix&sourceRepoId=40&targetRefId=refs%2Fheads%2Fmaster&targetRepoId=12"
+			if r.URL.RawQuery != want {
+				t.Errorf("Want %s but got %s\n", want, r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `[{"name": "bob"}, {"name": "carol"}]`)
+		case "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests":
+			var body struct {
+				Reviewers []Reviewer `json:"reviewers"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("Not expecting error: %v\n", err)
+			}
+			var names []string
+			for _, reviewer := range body.Reviewers {
+				names = append(names, reviewer.User.Name)
+			}
+			if fmt.Sprint(names) != "[carol dave Bob]" {
+				t.Errorf("Want [carol dave Bob] but got %v\n", names)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 1, "state": "OPEN"}`)
+		default:
+			t.Errorf("Not expecting %s\n", r.URL.Path)
+		}
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	from := NewPullRequestRef("~ALICE", "slug", "refs/heads/fix")
+	to := NewPullRequestRef("PROJ", "slug", "refs/heads/master")
+	if _, err := stashClient.CreatePullRequestWithDefaultReviewers("Fix", "", from, to, []string{"carol", "dave", "carol", "Bob"}); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(paths) != 4 {
+		t.Fatalf("Want 4 requests but got %v\n", paths)
+	}
+}
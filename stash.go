@@ -4,9 +4,8 @@ package stash
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -15,41 +14,153 @@ import (
 	"os"
 	"strings"
 	"time"
-
-	"github.com/ae6rt/retry"
 )
 
 var Log *log.Logger = log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lshortfile)
 
 type (
 	Stash interface {
+		AddInsightAnnotations(projectKey, repositorySlug, commitHash, key string, annotations []InsightAnnotation) error
+		ApprovePullRequest(projectKey, repositorySlug string, pullRequestID int) (Participant, error)
+		CanMerge(projectKey, repositorySlug string, pullRequestID int) (MergeStatus, error)
+		CompareChanges(projectKey, repositorySlug, from, to, fromRepository string) ([]Change, error)
+		CompareCommits(projectKey, repositorySlug, from, to, fromRepository string) ([]Commit, error)
+		CreateAnchoredComment(projectKey, repositorySlug, pullRequest, text string, anchor Anchor) (Comment, error)
 		CreateBranchRestriction(projectKey, repositorySlug, branch, user string) (BranchRestriction, error)
 		CreateComment(projectKey, repositorySlug, pullRequest, text string) (Comment, error)
-		GetComments(projectKey, repositorySlug, pullRequest, path string) ([]Comment, error)
+		CreateCrossRepositoryPullRequest(title, description string, from, to PullRequestRef, reviewers []string) (PullRequest, error)
+		CreateInsightReport(projectKey, repositorySlug, commitHash, key string, report InsightReport) (InsightReport, error)
 		CreatePullRequest(projectKey, repositorySlug, title, description, fromRef, toRef string, reviewers []string) (PullRequest, error)
+		CreatePullRequestWithDefaultReviewers(title, description string, from, to PullRequestRef, reviewers []string) (PullRequest, error)
 		CreateRepository(projectKey, slug string) (Repository, error)
+		CreateTask(commentID int, text string) (Task, error)
 		DeclinePullRequest(projectKey, repositorySlug string, pullRequestID, pullRequestVersion int) error
 		DeleteBranch(projectKey, repositorySlug, branchName string) error
 		DeleteBranchRestriction(projectKey, repositorySlug string, id int) error
+		DeleteComment(projectKey, repositorySlug, pullRequest string, commentID, commentVersion int) error
+		DeleteInsightReport(projectKey, repositorySlug, commitHash, key string) error
+		DeletePullRequest(projectKey, repositorySlug string, pullRequestID, version int) error
+		GetAllComments(projectKey, repositorySlug, pullRequest string) ([]Comment, error)
 		GetBranchRestrictions(projectKey, repositorySlug string) (BranchRestrictions, error)
 		GetBranches(projectKey, repositorySlug string) (map[string]Branch, error)
+		GetBuildStats(commitHash string) (BuildStats, error)
+		GetBuildStatuses(commitHash string) ([]BuildStatus, error)
+		GetComments(projectKey, repositorySlug, pullRequest, path string) ([]Comment, error)
 		GetCommit(projectKey, repositorySlug, commitHash string) (Commit, error)
+		GetCommitChanges(projectKey, repositorySlug, commitHash string) ([]Change, error)
+		GetCommitDiff(projectKey, repositorySlug, commitHash string, options DiffOptions) (Diffs, error)
+		GetCommitFileDiff(projectKey, repositorySlug, commitHash, path string, options DiffOptions) (Diffs, error)
 		GetCommits(projectKey, repositorySlug, commitSinceHash string, commitUntilHash string) (Commits, error)
+		GetCommitsWithOptions(projectKey, repositorySlug string, options CommitOptions) (Commits, error)
+		GetDefaultReviewerConditions(projectKey, repositorySlug string) ([]DefaultReviewerCondition, error)
+		GetDefaultReviewers(from, to PullRequestRef) ([]User, error)
+		GetInsightReport(projectKey, repositorySlug, commitHash, key string) (InsightReport, error)
+		GetProjectDefaultReviewerConditions(projectKey string) ([]DefaultReviewerCondition, error)
 		GetPullRequest(projectKey, repositorySlug, identifier string) (PullRequest, error)
-		GetPullRequestChanges(projectKey, repositorySlug string, prID int) ([]string, error)
+		GetPullRequestActivities(projectKey, repositorySlug string, pullRequestID int) ([]Activity, error)
+		GetPullRequestChangedPaths(projectKey, repositorySlug string, prID int) ([]string, error)
+		GetPullRequestChanges(projectKey, repositorySlug string, prID int) ([]Change, error)
+		GetPullRequestCommits(projectKey, repositorySlug string, pullRequestID int) ([]Commit, error)
+		GetPullRequestDiff(projectKey, repositorySlug string, pullRequestID int, options DiffOptions) (Diffs, error)
+		GetPullRequestFileDiff(projectKey, repositorySlug string, pullRequestID int, path string, options DiffOptions) (Diffs, error)
+		GetPullRequestJiraKeys(projectKey, repositorySlug string, pullRequestID int) ([]string, error)
+		GetPullRequestRawDiff(projectKey, repositorySlug string, pullRequestID int, options DiffOptions) ([]byte, error)
 		GetPullRequests(projectKey, repositorySlug, state string) ([]PullRequest, error)
+		GetPullRequestsWithOptions(projectKey, repositorySlug string, options PullRequestOptions) ([]PullRequest, error)
 		GetRawFile(projectKey, repositorySlug, branch, filePath string) ([]byte, error)
-		GetRepositories() (map[int]Repository, error)
 		GetRecentRepositories() (map[int]Repository, error)
+		GetRepositories() (map[int]Repository, error)
 		GetRepository(projectKey, repositorySlug string) (Repository, error)
 		GetTags(projectKey, repositorySlug string) (map[string]Tag, error)
+		GetTasks(projectKey, repositorySlug string, pullRequestID int) ([]Task, error)
+		MergePullRequest(projectKey, repositorySlug string, pullRequestID, pullRequestVersion int, strategy, message string) (PullRequest, error)
+		NeedsWorkPullRequest(projectKey, repositorySlug string, pullRequestID int) (Participant, error)
+		ReopenPullRequest(projectKey, repositorySlug string, pullRequestID, version int) (PullRequest, error)
+		ReopenTask(taskID int) (Task, error)
+		ReplyToComment(projectKey, repositorySlug, pullRequest string, parentID int, text string) (Comment, error)
+		ResolveTask(taskID int) (Task, error)
+		SetBuildStatus(commitHash string, status BuildStatus) error
+		SetParticipantStatus(projectKey, repositorySlug string, pullRequestID int, userSlug, status string) (Participant, error)
+		UnapprovePullRequest(projectKey, repositorySlug string, pullRequestID int) (Participant, error)
+		UpdateComment(projectKey, repositorySlug, pullRequest string, commentID, commentVersion int, text string) (Comment, error)
 		UpdatePullRequest(projectKey, repositorySlug, identifier string, version int, title, description, toRef string, reviewers []string) (PullRequest, error)
+		StashContext
+	}
+
+	// StashContext mirrors Stash with methods that take a context.Context.
+	StashContext interface {
+		AddInsightAnnotationsContext(ctx context.Context, projectKey, repositorySlug, commitHash, key string, annotations []InsightAnnotation) error
+		ApprovePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) (Participant, error)
+		CanMergeContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) (MergeStatus, error)
+		CompareChangesContext(ctx context.Context, projectKey, repositorySlug, from, to, fromRepository string) ([]Change, error)
+		CompareCommitsContext(ctx context.Context, projectKey, repositorySlug, from, to, fromRepository string) ([]Commit, error)
+		CreateAnchoredCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest, text string, anchor Anchor) (Comment, error)
+		CreateBranchRestrictionContext(ctx context.Context, projectKey, repositorySlug, branch, user string) (BranchRestriction, error)
+		CreateCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest, text string) (Comment, error)
+		CreateCrossRepositoryPullRequestContext(ctx context.Context, title, description string, from, to PullRequestRef, reviewers []string) (PullRequest, error)
+		CreateInsightReportContext(ctx context.Context, projectKey, repositorySlug, commitHash, key string, report InsightReport) (InsightReport, error)
+		CreatePullRequestContext(ctx context.Context, projectKey, repositorySlug, title, description, fromRef, toRef string, reviewers []string) (PullRequest, error)
+		CreatePullRequestWithDefaultReviewersContext(ctx context.Context, title, description string, from, to PullRequestRef, reviewers []string) (PullRequest, error)
+		CreateRepositoryContext(ctx context.Context, projectKey, slug string) (Repository, error)
+		CreateTaskContext(ctx context.Context, commentID int, text string) (Task, error)
+		DeclinePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID, pullRequestVersion int) error
+		DeleteBranchContext(ctx context.Context, projectKey, repositorySlug, branchName string) error
+		DeleteBranchRestrictionContext(ctx context.Context, projectKey, repositorySlug string, id int) error
+		DeleteCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest string, commentID, commentVersion int) error
+		DeleteInsightReportContext(ctx context.Context, projectKey, repositorySlug, commitHash, key string) error
+		DeletePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID, version int) error
+		GetAllCommentsContext(ctx context.Context, projectKey, repositorySlug, pullRequest string) ([]Comment, error)
+		GetBranchRestrictionsContext(ctx context.Context, projectKey, repositorySlug string) (BranchRestrictions, error)
+		GetBranchesContext(ctx context.Context, projectKey, repositorySlug string) (map[string]Branch, error)
+		GetBuildStatsContext(ctx context.Context, commitHash string) (BuildStats, error)
+		GetBuildStatusesContext(ctx context.Context, commitHash string) ([]BuildStatus, error)
+		GetCommentsContext(ctx context.Context, projectKey, repositorySlug, pullRequest, path string) ([]Comment, error)
+		GetCommitChangesContext(ctx context.Context, projectKey, repositorySlug, commitHash string) ([]Change, error)
+		GetCommitContext(ctx context.Context, projectKey, repositorySlug, commitHash string) (Commit, error)
+		GetCommitDiffContext(ctx context.Context, projectKey, repositorySlug, commitHash string, options DiffOptions) (Diffs, error)
+		GetCommitFileDiffContext(ctx context.Context, projectKey, repositorySlug, commitHash, path string, options DiffOptions) (Diffs, error)
+		GetCommitsContext(ctx context.Context, projectKey, repositorySlug, commitSinceHash string, commitUntilHash string) (Commits, error)
+		GetCommitsWithOptionsContext(ctx context.Context, projectKey, repositorySlug string, options CommitOptions) (Commits, error)
+		GetDefaultReviewerConditionsContext(ctx context.Context, projectKey, repositorySlug string) ([]DefaultReviewerCondition, error)
+		GetDefaultReviewersContext(ctx context.Context, from, to PullRequestRef) ([]User, error)
+		GetInsightReportContext(ctx context.Context, projectKey, repositorySlug, commitHash, key string) (InsightReport, error)
+		GetProjectDefaultReviewerConditionsContext(ctx context.Context, projectKey string) ([]DefaultReviewerCondition, error)
+		GetPullRequestActivitiesContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) ([]Activity, error)
+		GetPullRequestChangedPathsContext(ctx context.Context, projectKey, repositorySlug string, prID int) ([]string, error)
+		GetPullRequestChangesContext(ctx context.Context, projectKey, repositorySlug string, prID int) ([]Change, error)
+		GetPullRequestCommitsContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) ([]Commit, error)
+		GetPullRequestContext(ctx context.Context, projectKey, repositorySlug, identifier string) (PullRequest, error)
+		GetPullRequestDiffContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int, options DiffOptions) (Diffs, error)
+		GetPullRequestFileDiffContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int, path string, options DiffOptions) (Diffs, error)
+		GetPullRequestJiraKeysContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) ([]string, error)
+		GetPullRequestRawDiffContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int, options DiffOptions) ([]byte, error)
+		GetPullRequestsContext(ctx context.Context, projectKey, repositorySlug, state string) ([]PullRequest, error)
+		GetPullRequestsWithOptionsContext(ctx context.Context, projectKey, repositorySlug string, options PullRequestOptions) ([]PullRequest, error)
+		GetRawFileContext(ctx context.Context, projectKey, repositorySlug, branch, filePath string) ([]byte, error)
+		GetRecentRepositoriesContext(ctx context.Context) (map[int]Repository, error)
+		GetRepositoriesContext(ctx context.Context) (map[int]Repository, error)
+		GetRepositoryContext(ctx context.Context, projectKey, repositorySlug string) (Repository, error)
+		GetTagsContext(ctx context.Context, projectKey, repositorySlug string) (map[string]Tag, error)
+		GetTasksContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) ([]Task, error)
+		MergePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID, pullRequestVersion int, strategy, message string) (PullRequest, error)
+		NeedsWorkPullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) (Participant, error)
+		ReopenPullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID, version int) (PullRequest, error)
+		ReopenTaskContext(ctx context.Context, taskID int) (Task, error)
+		ReplyToCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest string, parentID int, text string) (Comment, error)
+		ResolveTaskContext(ctx context.Context, taskID int) (Task, error)
+		SetBuildStatusContext(ctx context.Context, commitHash string, status BuildStatus) error
+		SetParticipantStatusContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int, userSlug, status string) (Participant, error)
+		UnapprovePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) (Participant, error)
+		UpdateCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest string, commentID, commentVersion int, text string) (Comment, error)
+		UpdatePullRequestContext(ctx context.Context, projectKey, repositorySlug, identifier string, version int, title, description, toRef string, reviewers []string) (PullRequest, error)
 	}
 
 	Client struct {
-		userName string
-		password string
-		baseURL  *url.URL
+		auth       Authenticator
+		baseURL    *url.URL
+		httpClient *http.Client
+		retry      *RetryPolicy
+		pageLimit  int
 		Stash
 	}
 
@@ -138,20 +249,22 @@ type (
 	}
 
 	PullRequest struct {
-		ID          int        `id:"closed"`
-		Version     int        `json:"version"`
-		Closed      bool       `json:"closed"`
-		Open        bool       `json:"open"`
-		State       string     `json:"state"`
-		Title       string     `json:"title"`
-		Description string     `json:"description"`
-		FromRef     Ref        `json:"fromRef"`
-		ToRef       Ref        `json:"toRef"`
-		CreatedDate int64      `json:"createdDate"`
-		UpdatedDate int64      `json:"updatedDate"`
-		Reviewers   []Reviewer `json:"reviewers"`
-		Author      Author     `json:"author"`
-		Links       struct {
+		ID           int                   `json:"id"`
+		Version      int                   `json:"version"`
+		Closed       bool                  `json:"closed"`
+		Open         bool                  `json:"open"`
+		State        string                `json:"state"`
+		Title        string                `json:"title"`
+		Description  string                `json:"description"`
+		FromRef      Ref                   `json:"fromRef"`
+		ToRef        Ref                   `json:"toRef"`
+		CreatedDate  int64                 `json:"createdDate"`
+		UpdatedDate  int64                 `json:"updatedDate"`
+		Reviewers    []Reviewer            `json:"reviewers"`
+		Participants []Participant         `json:"participants"`
+		Author       Author                `json:"author"`
+		Properties   PullRequestProperties `json:"properties"`
+		Links        struct {
 			Self []struct {
 				Href string `json:"href"`
 			} `json:"self"`
@@ -159,29 +272,24 @@ type (
 	}
 
 	Comment struct {
-		ID       int       `json:"id"`
-		Text     string    `json:"text"`
-		Author   User      `json:"author"`
-		Anchor   Anchor    `json:"anchor,omitempty"`
-		Comments []Comment `json:"comments"`
-	}
-
+		ID          int       `json:"id"`
+		Version     int       `json:"version"`
+		Text        string    `json:"text"`
+		Author      User      `json:"author"`
+		CreatedDate int64     `json:"createdDate"`
+		UpdatedDate int64     `json:"updatedDate"`
+		Anchor      Anchor    `json:"anchor,omitempty"`
+		Comments    []Comment `json:"comments"`
+		Tasks       []Task    `json:"tasks"`
+	}
+
+	// Ref is a branch or tag of a pull request, with the repository it belongs
+	// to.  FromRef and ToRef repositories differ for pull requests from forks.
 	Ref struct {
-		DisplayID string `json:"displayId"`
-	}
-
-	errorResponse struct {
-		StatusCode int
-		Reason     string
-		error
-	}
-
-	stashError struct {
-		Errors []struct {
-			Context       string `json:"context"`
-			Message       string `json:"message"`
-			ExceptionName string `json:"exceptionName"`
-		} `json:"errors"`
+		ID           string                `json:"id"`
+		DisplayID    string                `json:"displayId"`
+		LatestCommit string                `json:"latestCommit"`
+		Repository   PullRequestRepository `json:"repository"`
 	}
 
 	// Pull Request Types
@@ -236,12 +344,31 @@ type (
 	}
 
 	CommentResource struct {
-		Text string `json:"text"`
+		Text   string         `json:"text"`
+		Anchor *Anchor        `json:"anchor,omitempty"`
+		Parent *CommentParent `json:"parent,omitempty"`
+	}
+
+	// CommentParent is the comment a reply answers.
+	CommentParent struct {
+		ID int `json:"id"`
 	}
 
+	// Anchor attaches a comment to a file of the pull request diff, or to a line
+	// of that file when Line is set.
 	Anchor struct {
 		Path    string `json:"path"`
-		SrcPath string `json:"srcPath"`
+		SrcPath string `json:"srcPath,omitempty"`
+		// Line is the line number in the file given by FileType; LineType tells
+		// whether the line was added, removed or is context.
+		Line     int    `json:"line,omitempty"`
+		LineType string `json:"lineType,omitempty"`
+		FileType string `json:"fileType,omitempty"`
+		// DiffType is the diff the comment was made on, with FromHash and ToHash
+		// for RANGE and COMMIT diffs.
+		DiffType string `json:"diffType,omitempty"`
+		FromHash string `json:"fromHash,omitempty"`
+		ToHash   string `json:"toHash,omitempty"`
 	}
 
 	Commit struct {
@@ -252,50 +379,87 @@ type (
 			EmailAddress string `json:"emailAddress"`
 		} `json:"author"`
 		AuthorTimestamp int64 `json:"authorTimestamp"` // in milliseconds since the epoch
-		Attributes      struct {
+		Committer       struct {
+			Name         string `json:"name"`
+			EmailAddress string `json:"emailAddress"`
+		} `json:"committer"`
+		CommitterTimestamp int64          `json:"committerTimestamp"` // in milliseconds since the epoch
+		Message            string         `json:"message"`
+		Parents            []CommitParent `json:"parents"`
+		Attributes         struct {
 			JiraKeys []string `json:"jira-key"`
 		} `json:"attributes"`
 	}
 
+	// CommitParent identifies a parent of a Commit.  Merge commits have several.
+	CommitParent struct {
+		ID        string `json:"id"`
+		DisplayID string `json:"displayId"`
+	}
+
 	Commits struct {
 		Commits []Commit `json:"values"`
 	}
+
+	// BuildStatus is the result of a build of a commit, such as a CI job, keyed
+	// by Key.  State is BuildInProgress, BuildSuccessful or BuildFailed.
+	BuildStatus struct {
+		State       string `json:"state"`
+		Key         string `json:"key"`
+		Name        string `json:"name,omitempty"`
+		URL         string `json:"url"`
+		Description string `json:"description,omitempty"`
+		DateAdded   int64  `json:"dateAdded,omitempty"` // in milliseconds since the epoch
+	}
+
+	// BuildStats counts the latest build statuses of a commit by state.
+	BuildStats struct {
+		Successful int `json:"successful"`
+		InProgress int `json:"inProgress"`
+		Failed     int `json:"failed"`
+	}
 )
 
 const (
 	stashPageLimit int = 25
 )
 
-var (
-	httpTransport = &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
+// Build states.
+const (
+	BuildInProgress = "INPROGRESS"
+	BuildSuccessful = "SUCCESSFUL"
+	BuildFailed     = "FAILED"
 )
 
 var (
-	httpClient *http.Client = &http.Client{Timeout: 30 * time.Second, Transport: httpTransport}
+	// httpClient is shared by clients that don't bring their own transport settings.
+	httpClient *http.Client = newHTTPClient(nil, nil, 30*time.Second)
 )
 
-func (e errorResponse) Error() string {
-	return fmt.Sprintf("%s (%d)", e.Reason, e.StatusCode)
-}
-
+// NewClient returns a Stash client that authenticates with userName and password,
+// or anonymously when both are empty.
+// TLS certificates are verified against the system roots; use NewClientWithOptions
+// for custom CAs, client certificates, proxies or timeouts.
 func NewClient(userName, password string, baseURL *url.URL) Stash {
-	return Client{userName: userName, password: password, baseURL: baseURL}
+	return Client{auth: BasicAuth{UserName: userName, Password: password}, baseURL: baseURL, httpClient: httpClient}
 }
 
 func (client Client) CreateRepository(projectKey, projectSlug string) (Repository, error) {
+	return client.CreateRepositoryContext(context.Background(), projectKey, projectSlug)
+}
+
+// CreateRepositoryContext is like CreateRepository but uses ctx for the request.
+func (client Client) CreateRepositoryContext(ctx context.Context, projectKey, projectSlug string) (Repository, error) {
 	slug := fmt.Sprintf(`{"name": "%s", "scmId": "git"}`, projectSlug)
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos", client.baseURL.String(), projectKey), bytes.NewBuffer([]byte(slug)))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos", client.baseURL.String(), projectKey), bytes.NewBuffer([]byte(slug)))
 	if err != nil {
 		return Repository{}, err
 	}
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-type", "application/json")
-	req.SetBasicAuth(client.userName, client.password)
 
-	responseCode, data, err := consumeResponse(req)
+	responseCode, data, err := client.consumeResponse(req)
 	if err != nil {
 		return Repository{}, err
 	}
@@ -311,7 +475,7 @@ func (client Client) CreateRepository(projectKey, projectSlug string) (Repositor
 		case responseCode == http.StatusConflict:
 			reason = "A repository with same name already exists."
 		}
-		return Repository{}, errorResponse{StatusCode: responseCode, Reason: reason}
+		return Repository{}, newAPIError(req, responseCode, data, reason)
 	}
 
 	var t Repository
@@ -325,256 +489,162 @@ func (client Client) CreateRepository(projectKey, projectSlug string) (Repositor
 
 // GetRepositories returns a map of repositories indexed by repository URL.
 func (client Client) GetRepositories() (map[int]Repository, error) {
-	start := 0
-	repositories := make(map[int]Repository)
-	morePages := true
-	for morePages {
-		retry := retry.New(3, retry.DefaultBackoffFunc)
-		var data []byte
-		work := func() error {
-			req, err := http.NewRequest("GET", fmt.Sprintf("%s/rest/api/1.0/repos?start=%d&limit=%d", client.baseURL.String(), start, stashPageLimit), nil)
-			if err != nil {
-				return err
-			}
-			req.Header.Set("Accept", "application/json")
-			// use credentials if we have them.  If not, the repository must be public.
-			if client.userName != "" && client.password != "" {
-				req.SetBasicAuth(client.userName, client.password)
-			}
+	return client.GetRepositoriesContext(context.Background())
+}
 
-			var responseCode int
-			responseCode, data, err = consumeResponse(req)
-			if err != nil {
-				return err
-			}
-			if responseCode != http.StatusOK {
-				var reason string = "unhandled reason"
-				switch {
-				case responseCode == http.StatusBadRequest:
-					reason = "Bad request."
-				}
-				return errorResponse{StatusCode: responseCode, Reason: reason}
-			}
-			return nil
-		}
-		if err := retry.Try(work); err != nil {
-			return nil, err
+// GetRepositoriesContext is like GetRepositories but uses ctx for every page it requests.
+func (client Client) GetRepositoriesContext(ctx context.Context) (map[int]Repository, error) {
+	repositories := make(map[int]Repository)
+	it := client.paginate(ctx, "/rest/api/1.0/repos", nil, PageOptions{}, func(responseCode int) string {
+		switch {
+		case responseCode == http.StatusBadRequest:
+			return "Bad request."
 		}
-
-		var r Repositories
-		err := json.Unmarshal(data, &r)
-		if err != nil {
+		return "unhandled reason"
+	})
+	for it.Next() {
+		var repo Repository
+		if err := it.Decode(&repo); err != nil {
 			return nil, err
 		}
-		for _, repo := range r.Repository {
-			repositories[repo.ID] = repo
-		}
-		morePages = !r.IsLastPage
-		start = r.NextPageStart
+		repositories[repo.ID] = repo
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
 	}
 	return repositories, nil
 }
 
 func (client Client) GetRecentRepositories() (map[int]Repository, error) {
-	start := 0
-	repositories := make(map[int]Repository)
-	morePages := true
-	for morePages {
-		retry := retry.New(3, retry.DefaultBackoffFunc)
-		var data []byte
-		work := func() error {
-			req, err := http.NewRequest("GET", fmt.Sprintf("%s/rest/api/1.0/profile/recent/repos?start=%d&limit=%d", client.baseURL.String(), start, stashPageLimit), nil)
-			if err != nil {
-				return err
-			}
-			req.Header.Set("Accept", "application/json")
-			// use credentials if we have them.  If not, the repository must be public.
-			if client.userName != "" && client.password != "" {
-				req.SetBasicAuth(client.userName, client.password)
-			}
+	return client.GetRecentRepositoriesContext(context.Background())
+}
 
-			var responseCode int
-			responseCode, data, err = consumeResponse(req)
-			if err != nil {
-				return err
-			}
-			if responseCode != http.StatusOK {
-				var reason string = "unhandled reason"
-				switch {
-				case responseCode == http.StatusBadRequest:
-					reason = "Bad request."
-				}
-				return errorResponse{StatusCode: responseCode, Reason: reason}
-			}
-			return nil
-		}
-		if err := retry.Try(work); err != nil {
-			return nil, err
+// GetRecentRepositoriesContext is like GetRecentRepositories but uses ctx for every page it requests.
+func (client Client) GetRecentRepositoriesContext(ctx context.Context) (map[int]Repository, error) {
+	repositories := make(map[int]Repository)
+	it := client.paginate(ctx, "/rest/api/1.0/profile/recent/repos", nil, PageOptions{}, func(responseCode int) string {
+		switch {
+		case responseCode == http.StatusBadRequest:
+			return "Bad request."
 		}
-
-		var r Repositories
-		err := json.Unmarshal(data, &r)
-		if err != nil {
+		return "unhandled reason"
+	})
+	for it.Next() {
+		var repo Repository
+		if err := it.Decode(&repo); err != nil {
 			return nil, err
 		}
-		for _, repo := range r.Repository {
-			repositories[repo.ID] = repo
-		}
-		morePages = !r.IsLastPage
-		start = r.NextPageStart
+		repositories[repo.ID] = repo
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
 	}
 	return repositories, nil
 }
 
 // GetBranches returns a map of branches indexed by branch display name for the given repository.
 func (client Client) GetBranches(projectKey, repositorySlug string) (map[string]Branch, error) {
-	start := 0
-	branches := make(map[string]Branch)
-	morePages := true
-	for morePages {
-		var data []byte
-		retry := retry.New(3, retry.DefaultBackoffFunc)
-		workit := func() error {
-			req, err := http.NewRequest("GET", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/branches?start=%d&limit=%d", client.baseURL.String(), projectKey, repositorySlug, start, stashPageLimit), nil)
-			if err != nil {
-				return err
-			}
-			req.Header.Set("Accept", "application/json")
-			req.SetBasicAuth(client.userName, client.password)
-
-			var responseCode int
-			responseCode, data, err = consumeResponse(req)
-			if err != nil {
-				return err
-			}
+	return client.GetBranchesContext(context.Background(), projectKey, repositorySlug)
+}
 
-			if responseCode != http.StatusOK {
-				var reason string = "unhandled reason"
-				switch {
-				case responseCode == http.StatusNotFound:
-					reason = "Not found"
-				case responseCode == http.StatusUnauthorized:
-					reason = "Unauthorized"
-				}
-				return errorResponse{StatusCode: responseCode, Reason: reason}
-			}
-			return nil
-		}
-		if err := retry.Try(workit); err != nil {
-			return nil, err
+// GetBranchesContext is like GetBranches but uses ctx for every page it requests.
+func (client Client) GetBranchesContext(ctx context.Context, projectKey, repositorySlug string) (map[string]Branch, error) {
+	branches := make(map[string]Branch)
+	it := client.paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/branches", projectKey, repositorySlug), nil, PageOptions{}, func(responseCode int) string {
+		switch {
+		case responseCode == http.StatusNotFound:
+			return "Not found"
+		case responseCode == http.StatusUnauthorized:
+			return "Unauthorized"
 		}
-
-		var r Branches
-		if err := json.Unmarshal(data, &r); err != nil {
+		return "unhandled reason"
+	})
+	for it.Next() {
+		var branch Branch
+		if err := it.Decode(&branch); err != nil {
 			return nil, err
 		}
-		for _, branch := range r.Branch {
-			branches[branch.DisplayID] = branch
-		}
-		morePages = !r.IsLastPage
-		start = r.NextPageStart
+		branches[branch.DisplayID] = branch
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
 	}
 	return branches, nil
 }
 
 // GetTags returns a map of tags indexed by tag display name for the given repository.
 func (client Client) GetTags(projectKey, repositorySlug string) (map[string]Tag, error) {
-	start := 0
-	tags := make(map[string]Tag)
-	morePages := true
-	for morePages {
-		var data []byte
-		retry := retry.New(3, retry.DefaultBackoffFunc)
-		work := func() error {
-			req, err := http.NewRequest("GET", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/tags?start=%d&limit=%d", client.baseURL.String(), projectKey, repositorySlug, start, stashPageLimit), nil)
-			if err != nil {
-				return err
-			}
-			req.Header.Set("Accept", "application/json")
-
-			// use credentials if we have them.  If not, the repository must be public.
-			if client.userName != "" && client.password != "" {
-				req.SetBasicAuth(client.userName, client.password)
-			}
-
-			var responseCode int
-			responseCode, data, err = consumeResponse(req)
-			if err != nil {
-				return err
-			}
+	return client.GetTagsContext(context.Background(), projectKey, repositorySlug)
+}
 
-			if responseCode != http.StatusOK {
-				var reason string = "unhandled reason"
-				switch {
-				case responseCode == http.StatusNotFound:
-					reason = "Not found"
-				case responseCode == http.StatusUnauthorized:
-					reason = "Unauthorized"
-				}
-				return errorResponse{StatusCode: responseCode, Reason: reason}
-			}
-			return nil
+// GetTagsContext is like GetTags but uses ctx for every page it requests.
+func (client Client) GetTagsContext(ctx context.Context, projectKey, repositorySlug string) (map[string]Tag, error) {
+	tags := make(map[string]Tag)
+	it := client.paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/tags", projectKey, repositorySlug), nil, PageOptions{}, func(responseCode int) string {
+		switch {
+		case responseCode == http.StatusNotFound:
+			return "Not found"
+		case responseCode == http.StatusUnauthorized:
+			return "Unauthorized"
 		}
-		if err := retry.Try(work); err != nil {
+		return "unhandled reason"
+	})
+	for it.Next() {
+		var tag Tag
+		if err := it.Decode(&tag); err != nil {
 			return nil, err
 		}
-
-		var r Tags
-		if err := json.Unmarshal(data, &r); err != nil {
-			return nil, err
-		}
-		for _, tag := range r.Tags {
-			tags[tag.DisplayID] = tag
-		}
-		morePages = !r.IsLastPage
-		start = r.NextPageStart
+		tags[tag.DisplayID] = tag
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
 	}
 	return tags, nil
 }
 
 // GetRepository returns a repository representation for the given Stash Project key and repository slug.
 func (client Client) GetRepository(projectKey, repositorySlug string) (Repository, error) {
-	retry := retry.New(3, retry.DefaultBackoffFunc)
+	return client.GetRepositoryContext(context.Background(), projectKey, repositorySlug)
+}
 
+// GetRepositoryContext is like GetRepository but uses ctx for the request.
+func (client Client) GetRepositoryContext(ctx context.Context, projectKey, repositorySlug string) (Repository, error) {
 	var r Repository
-	work := func() error {
-		req, err := http.NewRequest("GET", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s", client.baseURL.String(), projectKey, repositorySlug), nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Accept", "application/json")
-		// use credentials if we have them.  If not, the repository must be public.
-		if client.userName != "" && client.password != "" {
-			req.SetBasicAuth(client.userName, client.password)
-		}
-
-		responseCode, data, err := consumeResponse(req)
-		if err != nil {
-			return err
-		}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s", client.baseURL.String(), projectKey, repositorySlug), nil)
+	if err != nil {
+		return Repository{}, err
+	}
+	req.Header.Set("Accept", "application/json")
 
-		if responseCode != http.StatusOK {
-			var reason string = "unhandled reason"
-			switch {
-			case responseCode == http.StatusNotFound:
-				reason = "Not found"
-			case responseCode == http.StatusUnauthorized:
-				reason = "Unauthorized"
-			}
-			return errorResponse{StatusCode: responseCode, Reason: reason}
-		}
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return Repository{}, err
+	}
 
-		err = json.Unmarshal(data, &r)
-		if err != nil {
-			return err
+	if responseCode != http.StatusOK {
+		var reason string = "unhandled reason"
+		switch {
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
 		}
-		return nil
+		return Repository{}, newAPIError(req, responseCode, data, reason)
 	}
 
-	return r, retry.Try(work)
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		return Repository{}, err
+	}
+	return r, nil
 }
 
 func (client Client) CreateBranchRestriction(projectKey, repositorySlug, branch, user string) (BranchRestriction, error) {
+	return client.CreateBranchRestrictionContext(context.Background(), projectKey, repositorySlug, branch, user)
+}
+
+// CreateBranchRestrictionContext is like CreateBranchRestriction but uses ctx for the request.
+func (client Client) CreateBranchRestrictionContext(ctx context.Context, projectKey, repositorySlug, branch, user string) (BranchRestriction, error) {
 
 	branchPermission := BranchPermission{
 		Type:   "BRANCH",
@@ -588,16 +658,15 @@ func (client Client) CreateBranchRestriction(projectKey, repositorySlug, branch,
 		return BranchRestriction{}, err
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/rest/branch-permissions/1.0/projects/%s/repos/%s/restricted", client.baseURL.String(), projectKey, repositorySlug), bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/rest/branch-permissions/1.0/projects/%s/repos/%s/restricted", client.baseURL.String(), projectKey, repositorySlug), bytes.NewReader(data))
 	if err != nil {
 		return BranchRestriction{}, err
 	}
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-type", "application/json")
-	req.SetBasicAuth(client.userName, client.password)
 
-	responseCode, data, err := consumeResponse(req)
+	responseCode, data, err := client.consumeResponse(req)
 	if err != nil {
 		return BranchRestriction{}, err
 	}
@@ -613,7 +682,7 @@ func (client Client) CreateBranchRestriction(projectKey, repositorySlug, branch,
 		case responseCode == http.StatusConflict:
 			reason = "A branch restriction with same name already exists."
 		}
-		return BranchRestriction{}, errorResponse{StatusCode: responseCode, Reason: reason}
+		return BranchRestriction{}, newAPIError(req, responseCode, data, reason)
 	}
 
 	var t BranchRestriction
@@ -626,174 +695,117 @@ func (client Client) CreateBranchRestriction(projectKey, repositorySlug, branch,
 }
 
 func (client Client) GetBranchRestrictions(projectKey, repositorySlug string) (BranchRestrictions, error) {
-	retry := retry.New(3, retry.DefaultBackoffFunc)
+	return client.GetBranchRestrictionsContext(context.Background(), projectKey, repositorySlug)
+}
 
+// GetBranchRestrictionsContext is like GetBranchRestrictions but uses ctx for every page it requests.
+func (client Client) GetBranchRestrictionsContext(ctx context.Context, projectKey, repositorySlug string) (BranchRestrictions, error) {
 	var branchRestrictions BranchRestrictions
-	work := func() error {
-		req, err := http.NewRequest("GET", fmt.Sprintf("%s/rest/branch-permissions/1.0/projects/%s/repos/%s/restricted", client.baseURL.String(), projectKey, repositorySlug), nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Accept", "application/json")
-		req.SetBasicAuth(client.userName, client.password)
-
-		responseCode, data, err := consumeResponse(req)
-		if err != nil {
-			return err
-		}
-
-		if responseCode != http.StatusOK {
-			var reason string = "unhandled reason"
-			switch {
-			case responseCode == http.StatusNotFound:
-				reason = "Not found"
-			case responseCode == http.StatusUnauthorized:
-				reason = "Unauthorized"
-			}
-			return errorResponse{StatusCode: responseCode, Reason: reason}
+	it := client.paginate(ctx, fmt.Sprintf("/rest/branch-permissions/1.0/projects/%s/repos/%s/restricted", projectKey, repositorySlug), nil, PageOptions{}, func(responseCode int) string {
+		switch {
+		case responseCode == http.StatusNotFound:
+			return "Not found"
+		case responseCode == http.StatusUnauthorized:
+			return "Unauthorized"
 		}
-
-		err = json.Unmarshal(data, &branchRestrictions)
-		if err != nil {
-			return err
+		return "unhandled reason"
+	})
+	for it.Next() {
+		var restriction BranchRestriction
+		if err := it.Decode(&restriction); err != nil {
+			return BranchRestrictions{}, err
 		}
-		return nil
+		branchRestrictions.BranchRestriction = append(branchRestrictions.BranchRestriction, restriction)
 	}
-
-	return branchRestrictions, retry.Try(work)
+	if err := it.Err(); err != nil {
+		return BranchRestrictions{}, err
+	}
+	return branchRestrictions, nil
 }
 
 // DeleteBranchRestriction deletes a branch restriction
 func (client Client) DeleteBranchRestriction(projectKey, repositorySlug string, id int) error {
-	retry := retry.New(3, retry.DefaultBackoffFunc)
+	return client.DeleteBranchRestrictionContext(context.Background(), projectKey, repositorySlug, id)
+}
 
-	work := func() error {
-		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/rest/branch-permissions/1.0/projects/%s/repos/%s/restricted/%d", client.baseURL.String(), projectKey, repositorySlug, id), nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Accept", "application/json")
-		req.SetBasicAuth(client.userName, client.password)
+// DeleteBranchRestrictionContext is like DeleteBranchRestriction but uses ctx for the request.
+func (client Client) DeleteBranchRestrictionContext(ctx context.Context, projectKey, repositorySlug string, id int) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("%s/rest/branch-permissions/1.0/projects/%s/repos/%s/restricted/%d", client.baseURL.String(), projectKey, repositorySlug, id), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
 
-		responseCode, _, err := consumeResponse(req)
-		if err != nil {
-			return err
-		}
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
 
-		if responseCode != http.StatusNoContent {
-			var reason string = "unhandled reason"
-			switch {
-			case responseCode == http.StatusNotFound:
-				reason = "Not found"
-			case responseCode == http.StatusUnauthorized:
-				reason = "Unauthorized"
-			}
-			return errorResponse{StatusCode: responseCode, Reason: reason}
+	if responseCode != http.StatusNoContent {
+		var reason string = "unhandled reason"
+		switch {
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
 		}
-
-		return nil
+		return newAPIError(req, responseCode, data, reason)
 	}
-
-	return retry.Try(work)
+	return nil
 }
 
-// GetPullRequests returns a list of pull requests for a project / slug.
+// GetPullRequests returns a list of pull requests for a project / slug.  See
+// GetPullRequestsWithOptions for more filters.
 func (client Client) GetPullRequests(projectKey, projectSlug, state string) ([]PullRequest, error) {
-	start := 0
-	pullRequests := make([]PullRequest, 0)
-	morePages := true
-	for morePages {
-		retry := retry.New(3, retry.DefaultBackoffFunc)
-		var data []byte
-		work := func() error {
-			req, err := http.NewRequest("GET", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests?state=%s&start=%d&limit=%d", client.baseURL.String(), projectKey, projectSlug, state, start, stashPageLimit), nil)
-			if err != nil {
-				return err
-			}
-			req.Header.Set("Accept", "application/json")
-			req.SetBasicAuth(client.userName, client.password)
-
-			var responseCode int
-			responseCode, data, err = consumeResponse(req)
-			if err != nil {
-				return err
-			}
-			if responseCode != http.StatusOK {
-				var reason string = "unhandled reason"
-				switch {
-				case responseCode == http.StatusBadRequest:
-					reason = "Bad request."
-				}
-				return errorResponse{StatusCode: responseCode, Reason: reason}
-			}
-			return nil
-		}
-		if err := retry.Try(work); err != nil {
-			return nil, err
-		}
+	return client.GetPullRequestsContext(context.Background(), projectKey, projectSlug, state)
+}
 
-		var r PullRequests
-		err := json.Unmarshal(data, &r)
-		if err != nil {
-			return nil, err
-		}
-		for _, pr := range r.PullRequests {
-			pullRequests = append(pullRequests, pr)
-		}
-		morePages = !r.IsLastPage
-		start = r.NextPageStart
-	}
-	return pullRequests, nil
+// GetPullRequestsContext is like GetPullRequests but uses ctx for every page it requests.
+func (client Client) GetPullRequestsContext(ctx context.Context, projectKey, projectSlug, state string) ([]PullRequest, error) {
+	return client.GetPullRequestsWithOptionsContext(ctx, projectKey, projectSlug, PullRequestOptions{State: PullRequestState(state)})
 }
 
 // GetPullRequest returns a pull request for a project/slug with specified
 // identifier.
 func (client Client) GetPullRequest(projectKey, projectSlug, identifier string) (PullRequest, error) {
-	retry := retry.New(3, retry.DefaultBackoffFunc)
-	var data []byte
-	work := func() error {
-		req, err := http.NewRequest(
-			"GET",
-			fmt.Sprintf(
-				"%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s",
-				client.baseURL.String(), projectKey, projectSlug, identifier,
-			),
-			nil,
-		)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Accept", "application/json")
-		// use credentials if we have them.  If not, the repository must be public.
-		if client.userName != "" && client.password != "" {
-			req.SetBasicAuth(client.userName, client.password)
-		}
+	return client.GetPullRequestContext(context.Background(), projectKey, projectSlug, identifier)
+}
 
-		var responseCode int
-		responseCode, data, err = consumeResponse(req)
-		if err != nil {
-			return err
-		}
-		if responseCode != http.StatusOK {
-			var reason string = "unhandled reason"
-			switch {
-			case responseCode == http.StatusBadRequest:
-				reason = "Bad request."
-			case responseCode == http.StatusUnauthorized:
-				reason = "The currently authenticated user has insufficient permissions to see a pull request."
-			case responseCode == http.StatusNotFound:
-				reason = "The resource was not found. Does the project key exist?"
-			}
-			return errorResponse{StatusCode: responseCode, Reason: reason}
-		}
-		return nil
+// GetPullRequestContext is like GetPullRequest but uses ctx for the request.
+func (client Client) GetPullRequestContext(ctx context.Context, projectKey, projectSlug, identifier string) (PullRequest, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		fmt.Sprintf(
+			"%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s",
+			client.baseURL.String(), projectKey, projectSlug, identifier,
+		),
+		nil,
+	)
+	if err != nil {
+		return PullRequest{}, err
 	}
-	if err := retry.Try(work); err != nil {
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
 		return PullRequest{}, err
 	}
+	if responseCode != http.StatusOK {
+		var reason string = "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "Bad request."
+		case responseCode == http.StatusUnauthorized:
+			reason = "The currently authenticated user has insufficient permissions to see a pull request."
+		case responseCode == http.StatusNotFound:
+			reason = "The resource was not found. Does the project key exist?"
+		}
+		return PullRequest{}, newAPIError(req, responseCode, data, reason)
+	}
 
 	var r PullRequest
-	err := json.Unmarshal(data, &r)
+	err = json.Unmarshal(data, &r)
 	if err != nil {
 		return PullRequest{}, err
 	}
@@ -801,83 +813,86 @@ func (client Client) GetPullRequest(projectKey, projectSlug, identifier string)
 	return r, nil
 }
 
-func (client Client) GetPullRequestChanges(projectKey, repositorySlug string, prID int) ([]string, error) {
-	retry := retry.New(3, retry.DefaultBackoffFunc)
-	var data []byte
-	work := func() error {
-		req, err := http.NewRequest(
-			"GET",
-			fmt.Sprintf(
-				"%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/changes",
-				client.baseURL.String(), projectKey, repositorySlug, prID,
-			),
-			nil,
-		)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Accept", "application/json")
-		// use credentials if we have them.  If not, the repository must be public.
-		if client.userName != "" && client.password != "" {
-			req.SetBasicAuth(client.userName, client.password)
-		}
+// GetPullRequestChanges returns every file changed by a pull request.
+func (client Client) GetPullRequestChanges(projectKey, repositorySlug string, prID int) ([]Change, error) {
+	return client.GetPullRequestChangesContext(context.Background(), projectKey, repositorySlug, prID)
+}
 
-		var responseCode int
-		responseCode, data, err = consumeResponse(req)
-		if err != nil {
-			return err
+// GetPullRequestChangesContext is like GetPullRequestChanges but uses ctx for every page it requests.
+func (client Client) GetPullRequestChangesContext(ctx context.Context, projectKey, repositorySlug string, prID int) ([]Change, error) {
+	changes := make([]Change, 0)
+	it := client.paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/changes", projectKey, repositorySlug, prID), nil, PageOptions{}, func(responseCode int) string {
+		switch {
+		case responseCode == http.StatusBadRequest:
+			return "Bad request."
+		case responseCode == http.StatusUnauthorized:
+			return "The currently authenticated user has insufficient permissions to see a pull request."
+		case responseCode == http.StatusNotFound:
+			return "The resource was not found. Does the project key exist?"
 		}
-		if responseCode != http.StatusOK {
-			var reason string = "unhandled reason"
-			switch {
-			case responseCode == http.StatusBadRequest:
-				reason = "Bad request."
-			case responseCode == http.StatusUnauthorized:
-				reason = "The currently authenticated user has insufficient permissions to see a pull request."
-			case responseCode == http.StatusNotFound:
-				reason = "The resource was not found. Does the project key exist?"
-			}
-			return errorResponse{StatusCode: responseCode, Reason: reason}
+		return "unhandled reason"
+	})
+	for it.Next() {
+		var change Change
+		if err := it.Decode(&change); err != nil {
+			return nil, err
 		}
-		return nil
+		changes = append(changes, change)
 	}
-	if err := retry.Try(work); err != nil {
+	if err := it.Err(); err != nil {
 		return nil, err
 	}
+	return changes, nil
+}
 
-	var r struct {
-		Values []struct {
-			Path struct {
-				ToString string `json:"toString"`
-			} `json:"path"`
-		} `json:"values"`
-	}
+// GetPullRequestChangedPaths returns the paths of the files changed by a pull
+// request, as GetPullRequestChanges used to.
+func (client Client) GetPullRequestChangedPaths(projectKey, repositorySlug string, prID int) ([]string, error) {
+	return client.GetPullRequestChangedPathsContext(context.Background(), projectKey, repositorySlug, prID)
+}
 
-	err := json.Unmarshal(data, &r)
+// GetPullRequestChangedPathsContext is like GetPullRequestChangedPaths but uses ctx for every page it requests.
+func (client Client) GetPullRequestChangedPathsContext(ctx context.Context, projectKey, repositorySlug string, prID int) ([]string, error) {
+	changes, err := client.GetPullRequestChangesContext(ctx, projectKey, repositorySlug, prID)
 	if err != nil {
 		return nil, err
 	}
-
-	files := make([]string, 0, len(r.Values))
-	for _, v := range r.Values {
-		files = append(files, v.Path.ToString)
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, change.Path.ToString)
 	}
-
 	return files, nil
 }
 
 // CreateComment creates a comment for a pull-request.
 func (client Client) CreateComment(projectKey, repositorySlug, pullRequest, text string) (Comment, error) {
-	resource := CommentResource{
-		Text: text,
-	}
+	return client.CreateCommentContext(context.Background(), projectKey, repositorySlug, pullRequest, text)
+}
+
+// CreateCommentContext is like CreateComment but uses ctx for the request.
+func (client Client) CreateCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest, text string) (Comment, error) {
+	return client.postComment(ctx, projectKey, repositorySlug, pullRequest, CommentResource{Text: text})
+}
 
+// CreateAnchoredComment creates a comment on a file of a pull request, or on one
+// of its lines.
+func (client Client) CreateAnchoredComment(projectKey, repositorySlug, pullRequest, text string, anchor Anchor) (Comment, error) {
+	return client.CreateAnchoredCommentContext(context.Background(), projectKey, repositorySlug, pullRequest, text, anchor)
+}
+
+// CreateAnchoredCommentContext is like CreateAnchoredComment but uses ctx for the request.
+func (client Client) CreateAnchoredCommentContext(ctx context.Context, projectKey, repositorySlug, pullRequest, text string, anchor Anchor) (Comment, error) {
+	return client.postComment(ctx, projectKey, repositorySlug, pullRequest, CommentResource{Text: text, Anchor: &anchor})
+}
+
+func (client Client) postComment(ctx context.Context, projectKey, repositorySlug, pullRequest string, resource CommentResource) (Comment, error) {
 	reqBody, err := json.Marshal(resource)
 	if err != nil {
 		return Comment{}, err
 	}
 
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		fmt.Sprintf(
 			"%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s/comments",
@@ -894,9 +909,8 @@ func (client Client) CreateComment(projectKey, repositorySlug, pullRequest, text
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-type", "application/json")
-	req.SetBasicAuth(client.userName, client.password)
 
-	responseCode, data, err := consumeResponse(req)
+	responseCode, data, err := client.consumeResponse(req)
 	if err != nil {
 		return Comment{}, err
 	}
@@ -911,7 +925,7 @@ func (client Client) CreateComment(projectKey, repositorySlug, pullRequest, text
 			reason = "The resource was not found. Does the project key exist?"
 		}
 
-		return Comment{}, errorResponse{StatusCode: responseCode, Reason: reason}
+		return Comment{}, newAPIError(req, responseCode, data, reason)
 	}
 
 	var t Comment
@@ -923,60 +937,57 @@ func (client Client) CreateComment(projectKey, repositorySlug, pullRequest, text
 	return t, nil
 }
 
-// CreateComment creates a comment for a pull-request.
+// GetComments returns the comments on path, a file of a pull-request.
 func (client Client) GetComments(projectKey, repositorySlug, pullRequest, path string) ([]Comment, error) {
-	req, err := http.NewRequest(
-		"GET",
-		fmt.Sprintf(
-			"%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s/comments?path=%s",
-			client.baseURL.String(),
-			projectKey,
-			repositorySlug,
-			pullRequest,
-			path,
-		),
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-type", "application/json")
-	req.SetBasicAuth(client.userName, client.password)
+	return client.GetCommentsContext(context.Background(), projectKey, repositorySlug, pullRequest, path)
+}
 
-	responseCode, data, err := consumeResponse(req)
-	if err != nil {
-		return nil, err
-	}
-	if responseCode != http.StatusOK {
-		var reason string = "unknown reason"
+// GetCommentsContext is like GetComments but uses ctx for every page it requests.
+func (client Client) GetCommentsContext(ctx context.Context, projectKey, repositorySlug, pullRequest, path string) ([]Comment, error) {
+	var comments []Comment
+	it := client.paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s/comments", projectKey, repositorySlug, pullRequest), url.Values{"path": {path}}, PageOptions{}, func(responseCode int) string {
 		switch {
 		case responseCode == http.StatusBadRequest:
-			reason = "Cannot get comments due to a validation error."
+			return "Cannot get comments due to a validation error."
 		case responseCode == http.StatusUnauthorized:
-			reason = "The currently authenticated user has insufficient permissions to get comments."
+			return "The currently authenticated user has insufficient permissions to get comments."
 		case responseCode == http.StatusNotFound:
-			reason = "The resource was not found. Does the project key exist?"
+			return "The resource was not found. Does the project key exist?"
 		}
-
-		return nil, errorResponse{StatusCode: responseCode, Reason: reason}
-	}
-
-	var resp struct {
-		Values []Comment `json:"values"`
+		return "unknown reason"
+	})
+	for it.Next() {
+		var comment Comment
+		if err := it.Decode(&comment); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
 	}
-
-	err = json.Unmarshal(data, &resp)
-	if err != nil {
+	if err := it.Err(); err != nil {
 		return nil, err
 	}
-
-	return resp.Values, nil
+	return comments, nil
 }
 
 // CreatePullRequest creates a pull request between branches.
 func (client Client) CreatePullRequest(projectKey, repositorySlug, title, description, fromRef, toRef string, reviewers []string) (PullRequest, error) {
+	return client.CreatePullRequestContext(context.Background(), projectKey, repositorySlug, title, description, fromRef, toRef, reviewers)
+}
+
+// CreatePullRequestContext is like CreatePullRequest but uses ctx for the request.
+func (client Client) CreatePullRequestContext(ctx context.Context, projectKey, repositorySlug, title, description, fromRef, toRef string, reviewers []string) (PullRequest, error) {
+	return client.CreateCrossRepositoryPullRequestContext(ctx, title, description, NewPullRequestRef(projectKey, repositorySlug, fromRef), NewPullRequestRef(projectKey, repositorySlug, toRef), reviewers)
+}
+
+// CreateCrossRepositoryPullRequest creates a pull request from a branch of one
+// repository, such as a personal fork in a ~user project, into a branch of
+// another.  The pull request belongs to the repository of to.
+func (client Client) CreateCrossRepositoryPullRequest(title, description string, from, to PullRequestRef, reviewers []string) (PullRequest, error) {
+	return client.CreateCrossRepositoryPullRequestContext(context.Background(), title, description, from, to, reviewers)
+}
+
+// CreateCrossRepositoryPullRequestContext is like CreateCrossRepositoryPullRequest but uses ctx for the request.
+func (client Client) CreateCrossRepositoryPullRequestContext(ctx context.Context, title, description string, from, to PullRequestRef, reviewers []string) (PullRequest, error) {
 
 	var revs []Reviewer
 	for _, rev := range reviewers {
@@ -988,25 +999,9 @@ func (client Client) CreatePullRequest(projectKey, repositorySlug, title, descri
 	pullRequestResource := PullRequestResource{
 		Title:       title,
 		Description: description,
-		FromRef: PullRequestRef{
-			Id: fromRef,
-			Repository: PullRequestRepository{
-				Slug: repositorySlug,
-				Project: PullRequestProject{
-					Key: projectKey,
-				},
-			},
-		},
-		ToRef: PullRequestRef{
-			Id: toRef,
-			Repository: PullRequestRepository{
-				Slug: repositorySlug,
-				Project: PullRequestProject{
-					Key: projectKey,
-				},
-			},
-		},
-		Reviewers: revs,
+		FromRef:     from,
+		ToRef:       to,
+		Reviewers:   revs,
 	}
 
 	reqBody, err := json.Marshal(pullRequestResource)
@@ -1014,16 +1009,15 @@ func (client Client) CreatePullRequest(projectKey, repositorySlug, title, descri
 		return PullRequest{}, err
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests", client.baseURL.String(), projectKey, repositorySlug), bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests", client.baseURL.String(), to.Repository.Project.Key, to.Repository.Slug), bytes.NewBuffer(reqBody))
 	if err != nil {
 		return PullRequest{}, err
 	}
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-type", "application/json")
-	req.SetBasicAuth(client.userName, client.password)
 
-	responseCode, data, err := consumeResponse(req)
+	responseCode, data, err := client.consumeResponse(req)
 	if err != nil {
 		return PullRequest{}, err
 	}
@@ -1039,7 +1033,7 @@ func (client Client) CreatePullRequest(projectKey, repositorySlug, title, descri
 		case responseCode == http.StatusConflict:
 			reason = "A pull-request with same name already exists."
 		}
-		return PullRequest{}, errorResponse{StatusCode: responseCode, Reason: reason}
+		return PullRequest{}, newAPIError(req, responseCode, data, reason)
 	}
 
 	var t PullRequest
@@ -1053,6 +1047,11 @@ func (client Client) CreatePullRequest(projectKey, repositorySlug, title, descri
 
 // UpdatePullRequest update a pull request.
 func (client Client) UpdatePullRequest(projectKey, repositorySlug, identifier string, version int, title, description, toRef string, reviewers []string) (PullRequest, error) {
+	return client.UpdatePullRequestContext(context.Background(), projectKey, repositorySlug, identifier, version, title, description, toRef, reviewers)
+}
+
+// UpdatePullRequestContext is like UpdatePullRequest but uses ctx for the request.
+func (client Client) UpdatePullRequestContext(ctx context.Context, projectKey, repositorySlug, identifier string, version int, title, description, toRef string, reviewers []string) (PullRequest, error) {
 	var revs []Reviewer
 	for _, rev := range reviewers {
 		revs = append(revs, Reviewer{
@@ -1084,7 +1083,8 @@ func (client Client) UpdatePullRequest(projectKey, repositorySlug, identifier st
 		return PullRequest{}, err
 	}
 
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"PUT",
 		fmt.Sprintf(
 			"%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s",
@@ -1101,9 +1101,8 @@ func (client Client) UpdatePullRequest(projectKey, repositorySlug, identifier st
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-type", "application/json")
-	req.SetBasicAuth(client.userName, client.password)
 
-	responseCode, data, err := consumeResponse(req)
+	responseCode, data, err := client.consumeResponse(req)
 	if err != nil {
 		return PullRequest{}, err
 	}
@@ -1120,7 +1119,7 @@ func (client Client) UpdatePullRequest(projectKey, repositorySlug, identifier st
 		case responseCode == http.StatusConflict:
 			reason = "The pull-request was not updated due to a conflicts. Does the `from` and new `to` branch are different?"
 		}
-		return PullRequest{}, errorResponse{StatusCode: responseCode, Reason: reason}
+		return PullRequest{}, newAPIError(req, responseCode, data, reason)
 	}
 
 	var t PullRequest
@@ -1133,156 +1132,206 @@ func (client Client) UpdatePullRequest(projectKey, repositorySlug, identifier st
 }
 
 func (client Client) DeleteBranch(projectKey, repositorySlug, branchName string) error {
-	work := func() error {
-		buffer := bytes.NewBufferString((fmt.Sprintf(`{"name":"refs/heads/%s","dryRun":false}`, branchName)))
-		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/rest/branch-utils/1.0/projects/%s/repos/%s/branches", client.baseURL.String(), projectKey, repositorySlug), buffer)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-type", "application/json")
-		req.SetBasicAuth(client.userName, client.password)
+	return client.DeleteBranchContext(context.Background(), projectKey, repositorySlug, branchName)
+}
 
-		responseCode, _, err := consumeResponse(req)
-		if err != nil {
-			return err
-		}
+// DeleteBranchContext is like DeleteBranch but uses ctx for the request.
+func (client Client) DeleteBranchContext(ctx context.Context, projectKey, repositorySlug, branchName string) error {
+	buffer := bytes.NewBufferString((fmt.Sprintf(`{"name":"refs/heads/%s","dryRun":false}`, branchName)))
+	req, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("%s/rest/branch-utils/1.0/projects/%s/repos/%s/branches", client.baseURL.String(), projectKey, repositorySlug), buffer)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-type", "application/json")
 
-		switch responseCode {
-		case http.StatusNoContent:
-			return nil
-		case http.StatusBadRequest:
-			return errorResponse{StatusCode: responseCode, Reason: "Bad Requeest"}
-		case http.StatusUnauthorized:
-			return errorResponse{StatusCode: responseCode, Reason: "Unauthorized"}
-		default:
-			return errorResponse{StatusCode: responseCode, Reason: "(unhandled reason)"}
-		}
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+
+	switch responseCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusBadRequest:
+		return newAPIError(req, responseCode, data, "Bad Requeest")
+	case http.StatusUnauthorized:
+		return newAPIError(req, responseCode, data, "Unauthorized")
+	default:
+		return newAPIError(req, responseCode, data, "(unhandled reason)")
 	}
-	return retry.New(3, retry.DefaultBackoffFunc).Try(work)
 }
 
 func (client Client) GetRawFile(repositoryProjectKey, repositorySlug, filePath, branch string) ([]byte, error) {
-	retry := retry.New(3, retry.DefaultBackoffFunc)
+	return client.GetRawFileContext(context.Background(), repositoryProjectKey, repositorySlug, filePath, branch)
+}
 
-	var data []byte
-	work := func() error {
-		req, err := http.NewRequest("GET", fmt.Sprintf("%s/projects/%s/repos/%s/browse/%s?at=%s&raw", client.baseURL.String(), strings.ToLower(repositoryProjectKey), strings.ToLower(repositorySlug), filePath, branch), nil)
-		if err != nil {
-			return err
-		}
-		req.SetBasicAuth(client.userName, client.password)
+// GetRawFileContext is like GetRawFile but uses ctx for the request.
+func (client Client) GetRawFileContext(ctx context.Context, repositoryProjectKey, repositorySlug, filePath, branch string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/projects/%s/repos/%s/browse/%s?at=%s&raw", client.baseURL.String(), strings.ToLower(repositoryProjectKey), strings.ToLower(repositorySlug), filePath, branch), nil)
+	if err != nil {
+		return nil, err
+	}
 
-		var responseCode int
-		responseCode, data, err = consumeResponse(req)
-		if err != nil {
-			return err
-		}
-		if responseCode != http.StatusOK {
-			var reason string = "unhandled reason"
-			switch {
-			case responseCode == http.StatusNotFound:
-				reason = "Not found"
-			case responseCode == http.StatusUnauthorized:
-				reason = "Unauthorized"
-			}
-			return errorResponse{StatusCode: responseCode, Reason: reason}
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	if responseCode != http.StatusOK {
+		var reason string = "unhandled reason"
+		switch {
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
 		}
-		return nil
+		return nil, newAPIError(req, responseCode, data, reason)
 	}
-
-	return data, retry.Try(work)
+	return data, nil
 }
 
 // GetCommit returns a representation of the given commit hash.
 func (client Client) GetCommit(projectKey, repositorySlug, commitHash string) (Commit, error) {
-	retry := retry.New(3, retry.DefaultBackoffFunc)
-
-	var data []byte
-	work := func() error {
-		req, err := http.NewRequest("GET", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/commits/%s", client.baseURL.String(), projectKey, repositorySlug, commitHash), nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Accept", "application/json")
-
-		if client.userName != "" && client.password != "" {
-			req.SetBasicAuth(client.userName, client.password)
-		}
-
-		var responseCode int
-		responseCode, data, err = consumeResponse(req)
-		if err != nil {
-			return err
-		}
+	return client.GetCommitContext(context.Background(), projectKey, repositorySlug, commitHash)
+}
 
-		if responseCode != http.StatusOK {
-			var reason string = "unhandled reason"
-			switch {
-			case responseCode == http.StatusBadRequest:
-				reason = "Bad Request"
-			case responseCode == http.StatusUnauthorized:
-				reason = "Unauthorized"
-			case responseCode == http.StatusNotFound:
-				reason = "Not found"
-			}
-			return errorResponse{StatusCode: responseCode, Reason: reason}
-		}
-		return nil
+// GetCommitContext is like GetCommit but uses ctx for the request.
+func (client Client) GetCommitContext(ctx context.Context, projectKey, repositorySlug, commitHash string) (Commit, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/commits/%s", client.baseURL.String(), projectKey, repositorySlug, commitHash), nil)
+	if err != nil {
+		return Commit{}, err
 	}
+	req.Header.Set("Accept", "application/json")
 
-	if err := retry.Try(work); err != nil {
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
 		return Commit{}, err
 	}
 
+	if responseCode != http.StatusOK {
+		var reason string = "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "Bad Request"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return Commit{}, newAPIError(req, responseCode, data, reason)
+	}
+
 	var commit Commit
-	err := json.Unmarshal(data, &commit)
+	err = json.Unmarshal(data, &commit)
 	return commit, err
 }
 
-// GetCommits returns the commits between two hashes, inclusively.
-func (client Client) GetCommits(projectKey, repositorySlug, commitSinceHash string, commitUntilHash string) (Commits, error) {
-	retry := retry.New(3, retry.DefaultBackoffFunc)
+// SetBuildStatus records the status of a build of the given commit hash.  A
+// later status with the same key replaces it.
+func (client Client) SetBuildStatus(commitHash string, status BuildStatus) error {
+	return client.SetBuildStatusContext(context.Background(), commitHash, status)
+}
 
-	var data []byte
-	work := func() error {
-		req, err := http.NewRequest("GET", fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/commits?since=%s&until=%s&limit=1000", client.baseURL.String(), projectKey, repositorySlug, commitSinceHash, commitUntilHash), nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Accept", "application/json")
+// SetBuildStatusContext is like SetBuildStatus but uses ctx for the request.
+func (client Client) SetBuildStatusContext(ctx context.Context, commitHash string, status BuildStatus) error {
+	reqBody, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
 
-		if client.userName != "" && client.password != "" {
-			req.SetBasicAuth(client.userName, client.password)
-		}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/rest/build-status/1.0/commits/%s", client.baseURL.String(), commitHash), bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-type", "application/json")
 
-		var responseCode int
-		responseCode, data, err = consumeResponse(req)
-		if err != nil {
-			return err
+	// https://confluence.atlassian.com/cloudkb/xsrf-check-failed-when-calling-cloud-apis-826874382.html
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+
+	if responseCode != http.StatusNoContent {
+		var reason string = "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "Bad Request"
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
 		}
+		return newAPIError(req, responseCode, data, reason)
+	}
+	return nil
+}
 
-		if responseCode != http.StatusOK {
-			var reason string = "unhandled reason"
-			switch {
-			case responseCode == http.StatusBadRequest:
-				reason = "Bad Request"
-			case responseCode == http.StatusUnauthorized:
-				reason = "Unauthorized"
-			case responseCode == http.StatusNotFound:
-				reason = "Not found"
-			}
-			return errorResponse{StatusCode: responseCode, Reason: reason}
+// GetBuildStatuses returns the build statuses of the given commit hash, newest
+// first.
+func (client Client) GetBuildStatuses(commitHash string) ([]BuildStatus, error) {
+	return client.GetBuildStatusesContext(context.Background(), commitHash)
+}
+
+// GetBuildStatusesContext is like GetBuildStatuses but uses ctx for every page it requests.
+func (client Client) GetBuildStatusesContext(ctx context.Context, commitHash string) ([]BuildStatus, error) {
+	var statuses []BuildStatus
+	it := client.Paginate(ctx, fmt.Sprintf("/rest/build-status/1.0/commits/%s", commitHash), nil, PageOptions{})
+	for it.Next() {
+		var status BuildStatus
+		if err := it.Decode(&status); err != nil {
+			return nil, err
 		}
-		return nil
+		statuses = append(statuses, status)
 	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
 
-	if err := retry.Try(work); err != nil {
-		return Commits{}, err
+// GetBuildStats returns the number of successful, in progress and failed builds
+// of the given commit hash.
+func (client Client) GetBuildStats(commitHash string) (BuildStats, error) {
+	return client.GetBuildStatsContext(context.Background(), commitHash)
+}
+
+// GetBuildStatsContext is like GetBuildStats but uses ctx for the request.
+func (client Client) GetBuildStatsContext(ctx context.Context, commitHash string) (BuildStats, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/build-status/1.0/commits/stats/%s", client.baseURL.String(), commitHash), nil)
+	if err != nil {
+		return BuildStats{}, err
 	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return BuildStats{}, err
+	}
+
+	if responseCode != http.StatusOK {
+		var reason string = "unhandled reason"
+		switch {
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return BuildStats{}, newAPIError(req, responseCode, data, reason)
+	}
+
+	var stats BuildStats
+	err = json.Unmarshal(data, &stats)
+	return stats, err
+}
+
+// GetCommits returns the commits between two hashes, inclusively.
+func (client Client) GetCommits(projectKey, repositorySlug, commitSinceHash string, commitUntilHash string) (Commits, error) {
+	return client.GetCommitsContext(context.Background(), projectKey, repositorySlug, commitSinceHash, commitUntilHash)
+}
 
-	var commits Commits
-	err := json.Unmarshal(data, &commits)
-	return commits, err
+// GetCommitsContext is like GetCommits but uses ctx for every page it requests.
+func (client Client) GetCommitsContext(ctx context.Context, projectKey, repositorySlug, commitSinceHash string, commitUntilHash string) (Commits, error) {
+	return client.GetCommitsWithOptionsContext(ctx, projectKey, repositorySlug, CommitOptions{Since: commitSinceHash, Until: commitUntilHash})
 }
 
 func HasRepository(repositories map[int]Repository, url string) (Repository, bool) {
@@ -1296,26 +1345,6 @@ func HasRepository(repositories map[int]Repository, url string) (Repository, boo
 	return Repository{}, false
 }
 
-func IsRepositoryExists(err error) bool {
-	if err == nil {
-		return false
-	}
-	if response, ok := err.(errorResponse); ok {
-		return response.StatusCode == http.StatusConflict
-	}
-	return false
-}
-
-func IsRepositoryNotFound(err error) bool {
-	if err == nil {
-		return false
-	}
-	if response, ok := err.(errorResponse); ok {
-		return response.StatusCode == http.StatusNotFound
-	}
-	return false
-}
-
 // SshUrl extracts the SSH-based URL from the repository metadata.
 func (repo Repository) SshUrl() string {
 	for _, clone := range repo.Links.Clones {
@@ -1328,56 +1357,101 @@ func (repo Repository) SshUrl() string {
 
 // DeclinePullRequest declines a pull request
 func (client Client) DeclinePullRequest(projectKey, repositorySlug string, pullRequestID, version int) error {
-	retry := retry.New(3, retry.DefaultBackoffFunc)
-	work := func() error {
-		req, err := http.NewRequest(
-			"POST",
-			fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/decline?version=%d", client.baseURL.String(), projectKey, repositorySlug, pullRequestID, version),
-			nil,
-		)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Accept", "application/json")
+	return client.DeclinePullRequestContext(context.Background(), projectKey, repositorySlug, pullRequestID, version)
+}
+
+// DeclinePullRequestContext is like DeclinePullRequest but uses ctx for the request.
+func (client Client) DeclinePullRequestContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID, version int) error {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/decline?version=%d", client.baseURL.String(), projectKey, repositorySlug, pullRequestID, version),
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
 
-		// https://confluence.atlassian.com/cloudkb/xsrf-check-failed-when-calling-cloud-apis-826874382.html
-		req.Header.Set("X-Atlassian-Token", "no-check")
+	// https://confluence.atlassian.com/cloudkb/xsrf-check-failed-when-calling-cloud-apis-826874382.html
+	req.Header.Set("X-Atlassian-Token", "no-check")
 
-		req.SetBasicAuth(client.userName, client.password)
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
 
-		responseCode, _, err := consumeResponse(req)
-		if err != nil {
-			return err
+	if responseCode != http.StatusOK {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		case responseCode == http.StatusConflict:
+			reason = "Conflict"
 		}
+		return newAPIError(req, responseCode, data, reason)
+	}
+	return nil
+}
 
-		if responseCode != http.StatusOK {
-			reason := "unhandled reason"
-			switch {
-			case responseCode == http.StatusUnauthorized:
-				reason = "Unauthorized"
-			case responseCode == http.StatusNotFound:
-				reason = "Not found"
-			case responseCode == http.StatusConflict:
-				reason = "Conflict"
+// consumeResponse sends req, retrying it according to the client's RetryPolicy,
+// and returns the status code and body of the last response.  Error responses
+// are left to the caller, see newAPIError.
+func (client Client) consumeResponse(req *http.Request) (int, []byte, error) {
+	policy := DefaultRetryPolicy
+	if client.retry != nil {
+		policy = *client.retry
+	}
+
+	for attempt := 1; ; attempt++ {
+		if client.auth != nil {
+			if err := client.auth.Authenticate(req); err != nil {
+				return 0, nil, err
 			}
-			return errorResponse{StatusCode: responseCode, Reason: reason}
 		}
 
-		return nil
-	}
+		response, data, err := client.roundTrip(req)
+		if err != nil && req.Context().Err() != nil {
+			return 0, nil, req.Context().Err()
+		}
 
-	return retry.Try(work)
+		var (
+			statusCode int
+			header     http.Header
+		)
+		if response != nil {
+			statusCode, header = response.StatusCode, response.Header
+		}
+		if auth, ok := client.auth.(invalidator); ok && statusCode == http.StatusUnauthorized {
+			auth.Invalidate()
+		}
+		if delay, again := policy.retry(req.Method, attempt, statusCode, header, err); again {
+			if next, ok := rewind(req); ok {
+				if err := sleep(req.Context(), delay); err != nil {
+					return 0, nil, err
+				}
+				req = next
+				continue
+			}
+		}
+		if err != nil {
+			return statusCode, nil, err
+		}
+		return statusCode, data, nil
+	}
 }
 
-func consumeResponse(req *http.Request) (int, []byte, error) {
-	response, err := httpClient.Do(req)
-	if err != nil {
-		return 0, nil, err
+// roundTrip sends req once and reads the whole response body.
+func (client Client) roundTrip(req *http.Request) (*http.Response, []byte, error) {
+	hc := client.httpClient
+	if hc == nil {
+		hc = httpClient
 	}
-
-	data, err := ioutil.ReadAll(response.Body)
+	response, err := hc.Do(req)
 	if err != nil {
-		return response.StatusCode, nil, err
+		return nil, nil, err
 	}
 
 	defer func() {
@@ -1386,22 +1460,9 @@ func consumeResponse(req *http.Request) (int, []byte, error) {
 		}
 	}()
 
-	if response.StatusCode >= 400 {
-		// https://jira.xoom.com/browse/AS-42
-		contentType := response.Header.Get("Content-type")
-		if !strings.HasPrefix(contentType, "application/json") {
-			return response.StatusCode, data, nil
-		}
-
-		var errResponse stashError
-		if err := json.Unmarshal(data, &errResponse); err == nil {
-			var messages []string
-			for _, e := range errResponse.Errors {
-				messages = append(messages, e.Message)
-			}
-			return response.StatusCode, data, errors.New(strings.Join(messages, " "))
-		}
-		return response.StatusCode, nil, err
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return response, nil, err
 	}
-	return response.StatusCode, data, nil
+	return response, data, nil
 }
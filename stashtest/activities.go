@@ -0,0 +1,88 @@
+package stashtest
+
+import (
+	"net/http"
+
+	"github.com/xoom/stash"
+)
+
+// listActivities returns the history of a pull request, newest first.  Comment
+// activities render the current thread of their comment and disappear with it.
+func (s *Server) listActivities(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	var values []interface{}
+	for i := len(pr.activities) - 1; i >= 0; i-- {
+		a := pr.activities[i].Activity
+		if id := pr.activities[i].comment; id != 0 {
+			c := pr.comment(id)
+			if c == nil {
+				continue
+			}
+			thread := pr.thread(c)
+			a.Comment = &thread
+			if c.Anchor.Path != "" {
+				a.CommentAnchor = &c.Anchor
+			}
+		}
+		values = append(values, a)
+	}
+	writePage(w, r, values)
+}
+
+// addActivity records an event of pr, made by the user of r unless a.User is
+// set.  commentID links COMMENTED activities to a live comment.
+func (s *Server) addActivity(pr *pullRequest, r *http.Request, a stash.Activity, commentID int) {
+	s.nextActivityID++
+	a.ID = s.nextActivityID
+	a.CreatedDate = now()
+	if a.User.Name == "" {
+		a.User = s.currentUser(r)
+	}
+	pr.activities = append(pr.activities, &activity{Activity: a, comment: commentID})
+}
+
+// rescope records the commit added to branch of repo on every open pull
+// request from that branch.
+func (s *Server) rescope(repo *repository, branch, previous string, commit stash.Commit) {
+	for _, p := range s.projects {
+		for _, other := range p.repositories {
+			for _, pr := range other.pullRequests {
+				if pr.from != repo || pr.FromRef.DisplayID != branch || pr.State != "OPEN" {
+					continue
+				}
+				s.nextActivityID++
+				pr.activities = append(pr.activities, &activity{Activity: stash.Activity{
+					ID:               s.nextActivityID,
+					CreatedDate:      now(),
+					User:             stash.User{Name: commit.Author.Name},
+					Action:           stash.ActivityRescoped,
+					FromHash:         commit.ID,
+					PreviousFromHash: previous,
+					Added:            &stash.ActivityCommits{Commits: []stash.Commit{commit}, Total: 1},
+					Removed:          &stash.ActivityCommits{Commits: []stash.Commit{}},
+				}})
+			}
+		}
+	}
+}
+
+// reviewerDifference returns the users reviewing in a but not in b.
+func reviewerDifference(a, b []stash.Reviewer) []stash.User {
+	var users []stash.User
+	for _, reviewer := range a {
+		found := false
+		for _, other := range b {
+			if other.User.Name == reviewer.User.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			users = append(users, reviewer.User)
+		}
+	}
+	return users
+}
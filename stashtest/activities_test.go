@@ -0,0 +1,45 @@
+package stashtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestActivities(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	pr, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.CreateComment("PROJ", "widget", "1", "looks good"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	server.AddCommit("PROJ", "widget", "feature/readme", stash.Commit{})
+	if _, err := client.SetParticipantStatus("PROJ", "widget", pr.ID, "bob", stash.StatusApproved); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.MergePullRequest("PROJ", "widget", pr.ID, pr.Version, "", ""); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	activities, err := client.GetPullRequestActivities("PROJ", "widget", pr.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	var actions []string
+	for _, activity := range activities {
+		actions = append(actions, activity.Action)
+	}
+	want := "[MERGED APPROVED RESCOPED COMMENTED OPENED]"
+	if fmt.Sprint(actions) != want {
+		t.Fatalf("Want %s but got %v\n", want, actions)
+	}
+	if activities[0].Commit == nil || activities[1].User.Name != "bob" || activities[3].Comment.Text != "looks good" {
+		t.Fatalf("Want the merge commit, bob's approval and the comment but got %+v\n", activities)
+	}
+}
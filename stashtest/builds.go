@@ -0,0 +1,66 @@
+package stashtest
+
+import (
+	"net/http"
+
+	"github.com/xoom/stash"
+)
+
+func (s *Server) registerBuildStatusRoutes() {
+	const commits = "/rest/build-status/1.0/commits"
+	s.handle("POST", commits+"/{commit}", s.setBuildStatus)
+	s.handle("GET", commits+"/{commit}", s.listBuildStatuses)
+	s.handle("GET", commits+"/stats/{commit}", s.buildStats)
+}
+
+// setBuildStatus stores a status against any commit ID, known or not, like
+// Stash does.  A status with the key of an earlier one replaces it.
+func (s *Server) setBuildStatus(w http.ResponseWriter, r *http.Request, p params) {
+	var status stash.BuildStatus
+	if !decodeBody(w, r, &status) {
+		return
+	}
+	switch {
+	case status.State != stash.BuildInProgress && status.State != stash.BuildSuccessful && status.State != stash.BuildFailed:
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "state must be one of INPROGRESS, SUCCESSFUL or FAILED.")
+		return
+	case status.Key == "" || status.URL == "":
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "A build status needs a key and a url.")
+		return
+	}
+	status.DateAdded = now()
+
+	statuses := s.builds[p["commit"]]
+	for i, other := range statuses {
+		if other.Key == status.Key {
+			statuses = append(statuses[:i], statuses[i+1:]...)
+			break
+		}
+	}
+	s.builds[p["commit"]] = append(statuses, status)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listBuildStatuses(w http.ResponseWriter, r *http.Request, p params) {
+	statuses := s.builds[p["commit"]]
+	var values []interface{}
+	for i := len(statuses) - 1; i >= 0; i-- {
+		values = append(values, statuses[i])
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) buildStats(w http.ResponseWriter, r *http.Request, p params) {
+	var stats stash.BuildStats
+	for _, status := range s.builds[p["commit"]] {
+		switch status.State {
+		case stash.BuildSuccessful:
+			stats.Successful++
+		case stash.BuildInProgress:
+			stats.InProgress++
+		case stash.BuildFailed:
+			stats.Failed++
+		}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,43 @@
+package stashtest
+
+import (
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestBuildStatuses(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+	commit := server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+
+	for _, status := range []stash.BuildStatus{
+		{State: stash.BuildInProgress, Key: "ci", URL: "https://ci/1"},
+		{State: stash.BuildFailed, Key: "lint", URL: "https://lint/1"},
+		{State: stash.BuildSuccessful, Key: "ci", URL: "https://ci/1"},
+	} {
+		if err := client.SetBuildStatus(commit.ID, status); err != nil {
+			t.Fatalf("Not expecting error: %v\n", err)
+		}
+	}
+	err := client.SetBuildStatus(commit.ID, stash.BuildStatus{State: "BROKEN", Key: "ci", URL: "https://ci/1"})
+	if apiErr, ok := err.(*stash.APIError); !ok || apiErr.StatusCode != 400 {
+		t.Fatalf("Want bad request for an unknown state but got %v\n", err)
+	}
+
+	statuses, err := client.GetBuildStatuses(commit.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(statuses) != 2 || statuses[0].Key != "ci" || statuses[0].State != stash.BuildSuccessful || statuses[0].DateAdded == 0 {
+		t.Fatalf("Want the successful ci build then the failed lint but got %+v\n", statuses)
+	}
+	stats, err := client.GetBuildStats(commit.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if stats != (stash.BuildStats{Successful: 1, Failed: 1}) {
+		t.Fatalf("Want 1 successful and 1 failed but got %+v\n", stats)
+	}
+}
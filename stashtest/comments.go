@@ -0,0 +1,181 @@
+package stashtest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/xoom/stash"
+)
+
+// listComments returns the threads anchored to the path query parameter.
+func (s *Server) listComments(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	path := r.URL.Query().Get("path")
+
+	var values []interface{}
+	for _, c := range pr.comments {
+		if c.parent == 0 && c.Anchor.Path == path {
+			values = append(values, pr.thread(c))
+		}
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) createComment(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	var body stash.CommentResource
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.Text == "" {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "The comment text is required.")
+		return
+	}
+	if body.Anchor != nil && body.Anchor.Line > 0 && body.Anchor.LineType == "" {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "A line comment needs a lineType.")
+		return
+	}
+
+	created := now()
+	s.nextCommentID++
+	c := &comment{Comment: stash.Comment{
+		ID:          s.nextCommentID,
+		Text:        body.Text,
+		Author:      s.currentUser(r),
+		CreatedDate: created,
+		UpdatedDate: created,
+	}}
+	if body.Anchor != nil {
+		c.Anchor = *body.Anchor
+	}
+	if body.Parent != nil {
+		parent := pr.comment(body.Parent.ID)
+		if parent == nil {
+			writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.comment.NoSuchCommentException", fmt.Sprintf("No comment exists with ID %d.", body.Parent.ID))
+			return
+		}
+		c.parent, c.Anchor = parent.ID, parent.Anchor
+	}
+	pr.comments = append(pr.comments, c)
+	action := "ADDED"
+	if c.parent != 0 {
+		action = "REPLIED"
+	}
+	s.addActivity(pr, r, stash.Activity{Action: stash.ActivityCommented, CommentAction: action}, c.ID)
+	writeJSON(w, http.StatusCreated, c.Comment)
+}
+
+func (s *Server) updateComment(w http.ResponseWriter, r *http.Request, p params) {
+	pr, c, ok := s.comment(w, r, p)
+	if !ok {
+		return
+	}
+	var body struct {
+		Version int    `json:"version"`
+		Text    string `json:"text"`
+	}
+	if !decodeBody(w, r, &body) || !checkCommentVersion(w, c, body.Version) {
+		return
+	}
+	c.Text = body.Text
+	c.Version++
+	c.UpdatedDate = now()
+	s.addActivity(pr, r, stash.Activity{Action: stash.ActivityCommented, CommentAction: "EDITED"}, c.ID)
+	writeJSON(w, http.StatusOK, pr.thread(c))
+}
+
+func (s *Server) deleteComment(w http.ResponseWriter, r *http.Request, p params) {
+	pr, c, ok := s.comment(w, r, p)
+	if !ok {
+		return
+	}
+	version, _ := strconv.Atoi(r.URL.Query().Get("version"))
+	if !checkCommentVersion(w, c, version) {
+		return
+	}
+	for _, other := range pr.comments {
+		if other.parent == c.ID {
+			writeError(w, http.StatusConflict, "com.atlassian.bitbucket.comment.CommentDeletionException", "A comment which has replies cannot be deleted.")
+			return
+		}
+	}
+	for i, other := range pr.comments {
+		if other == c {
+			pr.comments = append(pr.comments[:i], pr.comments[i+1:]...)
+			break
+		}
+	}
+	var tasks []*stash.Task
+	for _, task := range pr.tasks {
+		if task.Anchor.ID != c.ID {
+			tasks = append(tasks, task)
+		}
+	}
+	pr.tasks = tasks
+
+	deleted := pr.thread(c)
+	s.addActivity(pr, r, stash.Activity{Action: stash.ActivityCommented, CommentAction: "DELETED", Comment: &deleted}, 0)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// comment resolves the {comment} parameter of a pull request route.  Only the
+// author of a comment may change it.
+func (s *Server) comment(w http.ResponseWriter, r *http.Request, p params) (*pullRequest, *comment, bool) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return nil, nil, false
+	}
+	id, _ := strconv.Atoi(p["comment"])
+	c := pr.comment(id)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.comment.NoSuchCommentException", fmt.Sprintf("No comment exists with ID %s.", p["comment"]))
+		return nil, nil, false
+	}
+	if c.Author.Name != s.currentUser(r).Name {
+		writeError(w, http.StatusForbidden, "com.atlassian.bitbucket.AuthorisationException", "You are not permitted to modify this comment.")
+		return nil, nil, false
+	}
+	return pr, c, true
+}
+
+func checkCommentVersion(w http.ResponseWriter, c *comment, version int) bool {
+	if version == c.Version {
+		return true
+	}
+	writeOutOfDate(w, "com.atlassian.bitbucket.comment.CommentOutOfDateException", "You are attempting to modify a comment based on out-of-date information.", c.Version, version)
+	return false
+}
+
+func (pr *pullRequest) comment(id int) *comment {
+	for _, c := range pr.comments {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// thread returns c with its replies nested, as Stash renders comments.
+func (pr *pullRequest) thread(c *comment) stash.Comment {
+	t := c.Comment
+	t.Comments = []stash.Comment{}
+	t.Tasks = []stash.Task{}
+	for _, task := range pr.tasks {
+		if task.Anchor.ID == c.ID {
+			t.Tasks = append(t.Tasks, *task)
+		}
+	}
+	for _, reply := range pr.comments {
+		if reply.parent == c.ID {
+			t.Comments = append(t.Comments, pr.thread(reply))
+		}
+	}
+	return t
+}
@@ -0,0 +1,79 @@
+package stashtest
+
+import (
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestAnchoredComments(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	if _, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	anchor := stash.Anchor{Path: "README.md", Line: 1, LineType: stash.LineAdded, FileType: stash.FileTo}
+	if _, err := client.CreateAnchoredComment("PROJ", "widget", "1", "typo", anchor); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.CreateComment("PROJ", "widget", "1", "looks good"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	comments, err := client.GetComments("PROJ", "widget", "1", "README.md")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(comments) != 1 || comments[0].Anchor != anchor {
+		t.Fatalf("Want the typo comment on README.md but got %+v\n", comments)
+	}
+}
+
+func TestCommentLifecycle(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	if _, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	status, err := client.CreateComment("PROJ", "widget", "1", "build running")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	question, err := client.CreateAnchoredComment("PROJ", "widget", "1", "why?", stash.Anchor{Path: "README.md"})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.ReplyToComment("PROJ", "widget", "1", question.ID, "because"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	status, err = client.UpdateComment("PROJ", "widget", "1", status.ID, status.Version, "build passing")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.UpdateComment("PROJ", "widget", "1", status.ID, 0, "build failing"); !stash.IsStaleVersion(err) {
+		t.Fatalf("Want stale version error but got %v\n", err)
+	}
+	if err := client.DeleteComment("PROJ", "widget", "1", question.ID, question.Version); !stash.IsConflict(err) {
+		t.Fatalf("Want conflict deleting a comment with replies but got %v\n", err)
+	}
+
+	comments, err := client.GetAllComments("PROJ", "widget", "1")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(comments) != 2 || comments[0].Text != "build passing" || comments[1].Comments[0].Text != "because" {
+		t.Fatalf("Want the status comment and the answered question but got %+v\n", comments)
+	}
+
+	if err := client.DeleteComment("PROJ", "widget", "1", status.ID, status.Version); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if comments, _ = client.GetAllComments("PROJ", "widget", "1"); len(comments) != 1 {
+		t.Fatalf("Want 1 comment left but got %d\n", len(comments))
+	}
+}
@@ -0,0 +1,72 @@
+package stashtest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/xoom/stash"
+)
+
+func (s *Server) registerCommitRoutes() {
+	const commit = "/rest/api/1.0/projects/{project}/repos/{repo}/commits/{commit}"
+	s.handle("GET", commit+"/changes", s.listCommitChanges)
+	s.handle("GET", commit+"/diff", s.getCommitDiff)
+	s.handle("GET", commit+"/diff/{path...}", s.getCommitDiff)
+}
+
+// commit resolves the {commit} parameter to a commit and the files of its
+// parent, answering 404 itself when there is no such commit.
+func (s *Server) commit(w http.ResponseWriter, p params) (*repository, stash.Commit, map[string]string, bool) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return nil, stash.Commit{}, nil, false
+	}
+	i := repo.commitIndex(p["commit"])
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.commit.NoSuchCommitException", fmt.Sprintf("Commit '%s' does not exist in repository '%s'.", p["commit"], repo.Slug))
+		return nil, stash.Commit{}, nil, false
+	}
+	commit := repo.commits[i]
+	return repo, commit, repo.parentTree(commit), true
+}
+
+// parentTree returns the files of the first parent of commit, or nil for the
+// first commit.
+func (repo *repository) parentTree(commit stash.Commit) map[string]string {
+	if id, ok := repo.parents[commit.ID]; ok {
+		return repo.tree(id)
+	}
+	return nil
+}
+
+// listCommitChanges compares a commit with its parent.  The first commit
+// adds every file it has.
+func (s *Server) listCommitChanges(w http.ResponseWriter, r *http.Request, p params) {
+	repo, commit, parent, ok := s.commit(w, p)
+	if !ok {
+		return
+	}
+	writePage(w, r, changes(parent, repo.tree(commit.ID)))
+}
+
+func (s *Server) getCommitDiff(w http.ResponseWriter, r *http.Request, p params) {
+	repo, commit, parent, ok := s.commit(w, p)
+	if !ok {
+		return
+	}
+	contextLines, whitespace := diffOptions(r)
+	diffs := stash.Diffs{
+		FromHash:     repo.parents[commit.ID],
+		ToHash:       commit.ID,
+		ContextLines: contextLines,
+		Whitespace:   whitespace,
+		Diffs:        []stash.Diff{},
+	}
+	for _, diff := range fileDiffs(parent, repo.tree(commit.ID), contextLines, whitespace) {
+		if path, ok := p["path"]; ok && !diffTouches(diff, path) {
+			continue
+		}
+		diffs.Diffs = append(diffs.Diffs, diff)
+	}
+	writeJSON(w, http.StatusOK, diffs)
+}
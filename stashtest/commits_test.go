@@ -0,0 +1,145 @@
+package stashtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestPullRequestCommits(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	var first, second stash.Commit
+	first.Attributes.JiraKeys = []string{"WID-1"}
+	first = server.AddCommit("PROJ", "widget", "feature/readme", first)
+	second.Attributes.JiraKeys = []string{"WID-2", "WID-1"}
+	second = server.AddCommit("PROJ", "widget", "feature/readme", second)
+	server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+
+	if _, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	commits, err := client.GetPullRequestCommits("PROJ", "widget", 1)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits) != 2 || commits[0].ID != second.ID || commits[1].ID != first.ID {
+		t.Fatalf("Want %s and %s but got %+v\n", second.ID, first.ID, commits)
+	}
+	keys, err := client.GetPullRequestJiraKeys("PROJ", "widget", 1)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if fmt.Sprint(keys) != "[WID-2 WID-1]" {
+		t.Fatalf("Want [WID-2 WID-1] but got %v\n", keys)
+	}
+}
+
+func TestCommitChanges(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+	first := server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+	server.AddFile("PROJ", "widget", "master", "README.md", "widget\nsecond line")
+	server.AddFile("PROJ", "widget", "master", "LICENSE", "MIT")
+	second := server.AddCommit("PROJ", "widget", "master", stash.Commit{Message: "Relicense"})
+	server.AddFile("PROJ", "widget", "master", "LICENSE", "Apache-2.0")
+
+	commit, err := client.GetCommit("PROJ", "widget", second.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if commit.Message != "Relicense" || len(commit.Parents) != 1 || commit.Parents[0].ID != first.ID {
+		t.Fatalf("Want message and parent %s but got %+v\n", first.ID, commit)
+	}
+
+	changes, err := client.GetCommitChanges("PROJ", "widget", first.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(changes) != 2 || changes[0].Path.ToString != "LICENSE" || changes[0].Type != stash.ChangeAdd || changes[1].Type != stash.ChangeModify {
+		t.Fatalf("Want LICENSE added and README.md modified but got %+v\n", changes)
+	}
+	changes, err = client.GetCommitChanges("PROJ", "widget", second.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(changes) != 1 || changes[0].Path.ToString != "LICENSE" || changes[0].Type != stash.ChangeModify {
+		t.Fatalf("Want LICENSE modified but got %+v\n", changes)
+	}
+
+	diffs, err := client.GetCommitFileDiff("PROJ", "widget", first.ID, "README.md", stash.DiffOptions{})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if diffs.ToHash != first.ID || len(diffs.Diffs) != 1 || diffs.Diffs[0].Hunks[0].DestinationSpan != 2 {
+		t.Fatalf("Want one README.md diff of two lines but got %+v\n", diffs)
+	}
+	diffs, err = client.GetCommitDiff("PROJ", "widget", first.ID, stash.DiffOptions{})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(diffs.Diffs) != 2 {
+		t.Fatalf("Want diffs of LICENSE and README.md but got %+v\n", diffs)
+	}
+	if _, err := client.GetCommitChanges("PROJ", "widget", "deadbeef"); !stash.IsNotFound(err) {
+		t.Fatalf("Want not found error but got %v\n", err)
+	}
+}
+
+func TestCommitFilters(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+	billing := server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+	server.AddFile("PROJ", "widget", "master", "services/billing/main.go", "package main")
+	server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+	server.AddFile("PROJ", "widget", "master", "services/search/main.go", "package main")
+	pr, err := client.CreatePullRequest("PROJ", "widget", "Readme", "", "feature/readme", "master", nil)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.MergePullRequest("PROJ", "widget", pr.ID, pr.Version, "", ""); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	commits, err := client.GetCommitsWithOptions("PROJ", "widget", stash.CommitOptions{Path: "services/billing"})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits.Commits) != 1 || commits.Commits[0].ID != billing.ID {
+		t.Fatalf("Want only %s but got %+v\n", billing.ID, commits.Commits)
+	}
+	commits, err = client.GetCommitsWithOptions("PROJ", "widget", stash.CommitOptions{Merges: stash.MergesOnly})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits.Commits) != 1 || len(commits.Commits[0].Parents) != 2 {
+		t.Fatalf("Want only the merge commit but got %+v\n", commits.Commits)
+	}
+	all, err := client.GetCommitsWithOptions("PROJ", "widget", stash.CommitOptions{})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	commits, err = client.GetCommitsWithOptions("PROJ", "widget", stash.CommitOptions{Merges: stash.MergesExclude})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits.Commits) != len(all.Commits)-1 {
+		t.Fatalf("Want all %d commits but the merge but got %+v\n", len(all.Commits), commits.Commits)
+	}
+
+	if _, err := client.GetCommitsWithOptions("PROJ", "widget", stash.CommitOptions{Since: "deadbeef"}); !stash.IsNotFound(err) {
+		t.Fatalf("Want not found error but got %v\n", err)
+	}
+	commits, err = client.GetCommitsWithOptions("PROJ", "widget", stash.CommitOptions{Since: "deadbeef", IgnoreMissing: true})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits.Commits) != 0 {
+		t.Fatalf("Want no commits but got %+v\n", commits.Commits)
+	}
+}
@@ -0,0 +1,100 @@
+package stashtest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/xoom/stash"
+)
+
+func (s *Server) registerCompareRoutes() {
+	const compare = "/rest/api/1.0/projects/{project}/repos/{repo}/compare"
+	s.handle("GET", compare+"/commits", s.compareCommits)
+	s.handle("GET", compare+"/changes", s.compareChanges)
+}
+
+// compareCommits lists the commits reachable from the from parameter but not
+// from to, newest first.
+func (s *Server) compareCommits(w http.ResponseWriter, r *http.Request, p params) {
+	from, fromID, to, toID, ok := s.compareRefs(w, r, p)
+	if !ok {
+		return
+	}
+	var values []interface{}
+	for _, commit := range commitsBetween(from, fromID, to, toID) {
+		values = append(values, commit)
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) compareChanges(w http.ResponseWriter, r *http.Request, p params) {
+	from, _, to, _, ok := s.compareRefs(w, r, p)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	writePage(w, r, changes(to.tree(query.Get("to")), from.tree(query.Get("from"))))
+}
+
+// compareRefs resolves the from, to and fromRepo parameters, answering the
+// error itself when one does not exist.
+func (s *Server) compareRefs(w http.ResponseWriter, r *http.Request, p params) (*repository, string, *repository, string, bool) {
+	to, ok := s.repository(w, p)
+	if !ok {
+		return nil, "", nil, "", false
+	}
+	query := r.URL.Query()
+	from := to
+	if name := query.Get("fromRepo"); name != "" {
+		if from = s.repositoryByName(name); from == nil {
+			writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.repository.NoSuchRepositoryException", fmt.Sprintf("Repository %s does not exist.", name))
+			return nil, "", nil, "", false
+		}
+	}
+	fromID, ok := from.resolve(query.Get("from"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.commit.NoSuchCommitException", fmt.Sprintf("Commit '%s' does not exist in repository '%s'.", query.Get("from"), from.Slug))
+		return nil, "", nil, "", false
+	}
+	toID, ok := to.resolve(query.Get("to"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.commit.NoSuchCommitException", fmt.Sprintf("Commit '%s' does not exist in repository '%s'.", query.Get("to"), to.Slug))
+		return nil, "", nil, "", false
+	}
+	return from, fromID, to, toID, true
+}
+
+// repositoryByName finds a repository by ID or by projectKey/slug.
+func (s *Server) repositoryByName(name string) *repository {
+	if id, err := strconv.Atoi(name); err == nil {
+		for _, repo := range s.sortedRepositories() {
+			if repo.ID == id {
+				return repo
+			}
+		}
+		return nil
+	}
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return s.lookup(parts[0], parts[1])
+}
+
+// commitsBetween walks back from fromID in from until it reaches history toID
+// in to already has.
+func commitsBetween(from *repository, fromID string, to *repository, toID string) []stash.Commit {
+	known := make(map[string]bool)
+	for id := toID; id != ""; id = to.parents[id] {
+		known[id] = true
+	}
+	var commits []stash.Commit
+	for id := fromID; id != "" && !known[id]; id = from.parents[id] {
+		if i := from.commitIndex(id); i >= 0 {
+			commits = append(commits, from.commits[i])
+		}
+	}
+	return commits
+}
@@ -0,0 +1,56 @@
+package stashtest
+
+import (
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestCompare(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+	release := server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+	server.AddFile("PROJ", "widget", "master", "CHANGES.md", "1.0")
+	server.AddTag("PROJ", "widget", "v1.0", release.ID)
+	fix := server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+	server.AddFile("PROJ", "widget", "master", "CHANGES.md", "1.0.1")
+	server.AddFile("PROJ", "widget", "master", "LICENSE", "MIT")
+
+	commits, err := client.CompareCommits("PROJ", "widget", "master", "refs/tags/v1.0", "")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits) != 1 || commits[0].ID != fix.ID {
+		t.Fatalf("Want only %s but got %+v\n", fix.ID, commits)
+	}
+	changes, err := client.CompareChanges("PROJ", "widget", "master", "v1.0", "")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(changes) != 2 || changes[0].Path.ToString != "CHANGES.md" || changes[0].Type != stash.ChangeModify || changes[1].Type != stash.ChangeAdd {
+		t.Fatalf("Want CHANGES.md modified and LICENSE added but got %+v\n", changes)
+	}
+	if _, err := client.CompareCommits("PROJ", "widget", "missing", "master", ""); !stash.IsNotFound(err) {
+		t.Fatalf("Want not found error but got %v\n", err)
+	}
+
+	server.AddProject("~ALICE")
+	server.AddRepository("~ALICE", "widget")
+	server.AddCommit("~ALICE", "widget", "fix", stash.Commit{})
+	server.AddFile("~ALICE", "widget", "fix", "README.md", "widget")
+	commits, err = client.CompareCommits("PROJ", "widget", "fix", "master", "~ALICE/widget")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("Want the fork's commit but got %+v\n", commits)
+	}
+	changes, err = client.CompareChanges("PROJ", "widget", "fix", "master", "~ALICE/widget")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(changes) != 2 || changes[0].Type != stash.ChangeDelete {
+		t.Fatalf("Want CHANGES.md and LICENSE deleted but got %+v\n", changes)
+	}
+}
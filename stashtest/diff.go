@@ -0,0 +1,241 @@
+package stashtest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/xoom/stash"
+)
+
+// defaultContextLines is the number of context lines Stash shows unless asked
+// otherwise.
+const defaultContextLines = 10
+
+// diffLine is a line of a line-by-line comparison, with its 1-based numbers in
+// the source and destination.  Added lines carry the number of the source line
+// they precede, and removed lines that of the destination line.
+type diffLine struct {
+	kind        string
+	source      int
+	destination int
+	text        string
+}
+
+// getDiff answers both the whole pull request diff and, with a {path...}
+// parameter, the diff of a single file.
+func (s *Server) getDiff(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	contextLines, whitespace := diffOptions(r)
+	diffs := stash.Diffs{
+		FromHash:     pr.to.head(pr.ToRef.DisplayID),
+		ToHash:       pr.from.head(pr.FromRef.DisplayID),
+		ContextLines: contextLines,
+		Whitespace:   whitespace,
+		Diffs:        []stash.Diff{},
+	}
+	for _, diff := range pr.diffs(contextLines, whitespace) {
+		if path, ok := p["path"]; ok && !diffTouches(diff, path) {
+			continue
+		}
+		diffs.Diffs = append(diffs.Diffs, diff)
+	}
+	writeJSON(w, http.StatusOK, diffs)
+}
+
+// getRawDiff renders the pull request diff as git does.
+func (s *Server) getRawDiff(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	for _, diff := range pr.diffs(diffOptions(r)) {
+		source, destination := "/dev/null", "/dev/null"
+		if diff.Source != nil {
+			source = "a/" + diff.Source.ToString
+		}
+		if diff.Destination != nil {
+			destination = "b/" + diff.Destination.ToString
+		}
+		name := diff.Destination
+		if name == nil {
+			name = diff.Source
+		}
+		fmt.Fprintf(&buf, "diff --git a/%s b/%s\n--- %s\n+++ %s\n", name.ToString, name.ToString, source, destination)
+		for _, hunk := range diff.Hunks {
+			fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(hunk.SourceLine, hunk.SourceSpan), hunkRange(hunk.DestinationLine, hunk.DestinationSpan))
+			for _, segment := range hunk.Segments {
+				prefix := map[string]string{stash.LineAdded: "+", stash.LineRemoved: "-", stash.LineContext: " "}[segment.Type]
+				for _, line := range segment.Lines {
+					fmt.Fprintf(&buf, "%s%s\n", prefix, line.Line)
+				}
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain;charset=UTF-8")
+	w.Write(buf.Bytes())
+}
+
+func diffOptions(r *http.Request) (int, string) {
+	contextLines := defaultContextLines
+	if n, err := strconv.Atoi(r.URL.Query().Get("contextLines")); err == nil && n >= 0 {
+		contextLines = n
+	}
+	whitespace := r.URL.Query().Get("whitespace")
+	if whitespace != stash.WhitespaceIgnoreAll {
+		whitespace = stash.WhitespaceShow
+	}
+	return contextLines, whitespace
+}
+
+func diffTouches(diff stash.Diff, path string) bool {
+	return (diff.Source != nil && diff.Source.ToString == path) || (diff.Destination != nil && diff.Destination.ToString == path)
+}
+
+// hunkRange formats the range of a hunk header.  Empty ranges point at the line
+// before them, as in git.
+func hunkRange(line, span int) string {
+	if span == 0 {
+		line--
+	}
+	return fmt.Sprintf("%d,%d", line, span)
+}
+
+// diffs compares the files of the target branch with those of the source.
+func (pr *pullRequest) diffs(contextLines int, whitespace string) []stash.Diff {
+	return fileDiffs(pr.to.files[pr.ToRef.DisplayID], pr.from.files[pr.FromRef.DisplayID], contextLines, whitespace)
+}
+
+// fileDiffs compares two sets of files, leaving out those that differ only in
+// ignored whitespace.
+func fileDiffs(from, to map[string]string, contextLines int, whitespace string) []stash.Diff {
+	var diffs []stash.Diff
+	for _, path := range changedPaths(from, to) {
+		var diff stash.Diff
+		before, inSource := from[path]
+		after, inDestination := to[path]
+		if inSource {
+			p := newPath(path)
+			diff.Source = &p
+		}
+		if inDestination {
+			p := newPath(path)
+			diff.Destination = &p
+		}
+		diff.Hunks = hunks(compareLines(splitLines(before), splitLines(after), whitespace == stash.WhitespaceIgnoreAll), contextLines)
+		if len(diff.Hunks) == 0 {
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// compareLines diffs a against b through their longest common subsequence.
+func compareLines(a, b []string, ignoreWhitespace bool) []diffLine {
+	equal := func(x, y string) bool {
+		if ignoreWhitespace {
+			return strings.Join(strings.Fields(x), "") == strings.Join(strings.Fields(y), "")
+		}
+		return x == y
+	}
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case equal(a[i], b[j]):
+				common[i][j] = common[i+1][j+1] + 1
+			case common[i+1][j] >= common[i][j+1]:
+				common[i][j] = common[i+1][j]
+			default:
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && equal(a[i], b[j]):
+			lines = append(lines, diffLine{stash.LineContext, i + 1, j + 1, b[j]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, diffLine{stash.LineRemoved, i + 1, j + 1, a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{stash.LineAdded, i + 1, j + 1, b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// hunks groups changed lines with up to contextLines unchanged lines around
+// them.  Changes closer than twice that share a hunk.
+func hunks(lines []diffLine, contextLines int) []stash.Hunk {
+	var result []stash.Hunk
+	for start := 0; start < len(lines); {
+		first := start
+		for first < len(lines) && lines[first].kind == stash.LineContext {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for next := first + 1; next < len(lines) && next-last <= 2*contextLines+1; next++ {
+			if lines[next].kind != stash.LineContext {
+				last = next
+			}
+		}
+
+		begin, end := first-contextLines, last+contextLines+1
+		if begin < start {
+			begin = start
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+		result = append(result, hunk(lines[begin:end]))
+		start = end
+	}
+	return result
+}
+
+func hunk(lines []diffLine) stash.Hunk {
+	h := stash.Hunk{SourceLine: lines[0].source, DestinationLine: lines[0].destination}
+	for _, line := range lines {
+		if line.kind != stash.LineAdded {
+			h.SourceSpan++
+		}
+		if line.kind != stash.LineRemoved {
+			h.DestinationSpan++
+		}
+		if n := len(h.Segments); n == 0 || h.Segments[n-1].Type != line.kind {
+			h.Segments = append(h.Segments, stash.Segment{Type: line.kind})
+		}
+		segment := &h.Segments[len(h.Segments)-1]
+		segment.Lines = append(segment.Lines, stash.DiffLine{Source: line.source, Destination: line.destination, Line: line.text})
+	}
+	return h
+}
@@ -0,0 +1,61 @@
+package stashtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestDiffs(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	server.AddFile("PROJ", "widget", "master", "main.go", "a\nb\nc\nd\ne\nf\ng\n")
+	server.AddFile("PROJ", "widget", "feature/readme", "main.go", "a\nB\nc\nd\ne\nf\n  g\nh\n")
+	client := server.Client()
+
+	if _, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	diffs, err := client.GetPullRequestDiff("PROJ", "widget", 1, stash.DiffOptions{})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(diffs.Diffs) != 3 || diffs.Diffs[1].Source != nil || diffs.Diffs[1].Destination.ToString != "docs/usage.md" {
+		t.Fatalf("Want README.md, an added docs/usage.md and main.go but got %+v\n", diffs.Diffs)
+	}
+
+	diffs, err = client.GetPullRequestFileDiff("PROJ", "widget", 1, "main.go", stash.DiffOptions{ContextLines: 1})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	hunks := diffs.Diffs[0].Hunks
+	if len(hunks) != 2 {
+		t.Fatalf("Want 2 hunks but got %+v\n", hunks)
+	}
+	if h := hunks[0]; h.SourceLine != 1 || h.SourceSpan != 3 || h.DestinationSpan != 3 || h.Segments[1].Type != stash.LineRemoved || h.Segments[2].Lines[0].Line != "B" {
+		t.Fatalf("Want b replaced by B with one line of context but got %+v\n", h)
+	}
+	if h := hunks[1]; h.SourceLine != 6 || h.DestinationLine != 6 || h.DestinationSpan != 3 {
+		t.Fatalf("Want the hunk at line 6 but got %+v\n", h)
+	}
+
+	diffs, err = client.GetPullRequestFileDiff("PROJ", "widget", 1, "main.go", stash.DiffOptions{ContextLines: stash.NoContextLines, Whitespace: stash.WhitespaceIgnoreAll})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if hunks := diffs.Diffs[0].Hunks; len(hunks) != 2 || hunks[1].Segments[0].Type != stash.LineAdded || hunks[1].Segments[0].Lines[0].Line != "h" {
+		t.Fatalf("Want only h added after b ignoring whitespace but got %+v\n", hunks)
+	}
+
+	raw, err := client.GetPullRequestRawDiff("PROJ", "widget", 1, stash.DiffOptions{})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	want := "diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -1,1 +1,1 @@\n-widget\n+widget, improved\n" +
+		"diff --git a/docs/usage.md b/docs/usage.md\n--- /dev/null\n+++ b/docs/usage.md\n@@ -0,0 +1,1 @@\n+usage\n"
+	if !strings.HasPrefix(string(raw), want) {
+		t.Fatalf("Want %q but got %q\n", want, raw)
+	}
+}
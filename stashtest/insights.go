@@ -0,0 +1,127 @@
+package stashtest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/xoom/stash"
+)
+
+// maxAnnotationsPerReport is the number of annotations a report keeps, however
+// many requests they were added in.
+const maxAnnotationsPerReport = 1000
+
+// report is a Code Insights report with its annotations.
+type report struct {
+	stash.InsightReport
+	annotations []stash.InsightAnnotation
+}
+
+func (s *Server) registerInsightRoutes() {
+	const report = "/rest/insights/1.0/projects/{project}/repos/{repo}/commits/{commit}/reports/{key}"
+	s.handle("PUT", report, s.putReport)
+	s.handle("GET", report, s.getReport)
+	s.handle("DELETE", report, s.deleteReport)
+	s.handle("POST", report+"/annotations", s.addAnnotations)
+}
+
+// Annotations returns the annotations of the report with key on commitID.
+func (s *Server) Annotations(projectKey, slug, commitID, key string) []stash.InsightAnnotation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.mustRepository(projectKey, slug).reports[commitID+"/"+key]; ok {
+		return append([]stash.InsightAnnotation(nil), r.annotations...)
+	}
+	return nil
+}
+
+// putReport creates or replaces a report, dropping the annotations of the one
+// it replaces as Stash does.
+func (s *Server) putReport(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	var body stash.InsightReport
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.Title == "" {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "A report needs a title.")
+		return
+	}
+	if body.Result != "" && body.Result != stash.InsightPass && body.Result != stash.InsightFail {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", fmt.Sprintf("%s is not a valid result.", body.Result))
+		return
+	}
+	for _, data := range body.Data {
+		switch data.Type {
+		case stash.InsightBoolean, stash.InsightDate, stash.InsightDuration, stash.InsightLink, stash.InsightNumber, stash.InsightPercentage, stash.InsightText:
+		default:
+			writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", fmt.Sprintf("%s is not a valid data type.", data.Type))
+			return
+		}
+	}
+	body.Key = p["key"]
+	body.CreatedDate = now()
+	repo.reports[p["commit"]+"/"+p["key"]] = &report{InsightReport: body}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) getReport(w http.ResponseWriter, r *http.Request, p params) {
+	_, rep, ok := s.report(w, p)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, rep.InsightReport)
+}
+
+func (s *Server) deleteReport(w http.ResponseWriter, r *http.Request, p params) {
+	repo, _, ok := s.report(w, p)
+	if !ok {
+		return
+	}
+	delete(repo.reports, p["commit"]+"/"+p["key"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) addAnnotations(w http.ResponseWriter, r *http.Request, p params) {
+	_, rep, ok := s.report(w, p)
+	if !ok {
+		return
+	}
+	var body struct {
+		Annotations []stash.InsightAnnotation `json:"annotations"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if len(rep.annotations)+len(body.Annotations) > maxAnnotationsPerReport {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", fmt.Sprintf("A report can have at most %d annotations.", maxAnnotationsPerReport))
+		return
+	}
+	for _, annotation := range body.Annotations {
+		if annotation.Message == "" || (annotation.Severity != stash.SeverityLow && annotation.Severity != stash.SeverityMedium && annotation.Severity != stash.SeverityHigh) {
+			writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "An annotation needs a message and a severity of LOW, MEDIUM or HIGH.")
+			return
+		}
+	}
+	rep.annotations = append(rep.annotations, body.Annotations...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// report resolves the {commit} and {key} parameters, answering 404 itself when
+// there is no such report.
+func (s *Server) report(w http.ResponseWriter, p params) (*repository, *report, bool) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return nil, nil, false
+	}
+	rep, ok := repo.reports[p["commit"]+"/"+p["key"]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.insights.NoSuchReportException", fmt.Sprintf("No report with key %s exists for commit %s.", p["key"], p["commit"]))
+		return nil, nil, false
+	}
+	return repo, rep, true
+}
@@ -0,0 +1,60 @@
+package stashtest
+
+import (
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestInsights(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+	commit := server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+
+	if _, err := client.GetInsightReport("PROJ", "widget", commit.ID, "sast"); !stash.IsNotFound(err) {
+		t.Fatalf("Want not found error but got %v\n", err)
+	}
+	report, err := client.CreateInsightReport("PROJ", "widget", commit.ID, "sast", stash.InsightReport{
+		Title:  "Security scan",
+		Result: stash.InsightPass,
+		Data:   []stash.InsightData{stash.NewNumberData("Findings", 0), stash.NewLinkData("Details", "Job", "https://ci/1")},
+	})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if report.Key != "sast" || report.CreatedDate == 0 {
+		t.Fatalf("Want the sast report but got %+v\n", report)
+	}
+	fetched, err := client.GetInsightReport("PROJ", "widget", commit.ID, "sast")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if fetched.Title != "Security scan" || len(fetched.Data) != 2 || fetched.Data[1].Type != stash.InsightLink {
+		t.Fatalf("Want the security scan with its data but got %+v\n", fetched)
+	}
+
+	annotations := make([]stash.InsightAnnotation, 1000)
+	for i := range annotations {
+		annotations[i] = stash.InsightAnnotation{Path: "README.md", Line: 1, Message: "typo", Severity: stash.SeverityLow}
+	}
+	if err := client.AddInsightAnnotations("PROJ", "widget", commit.ID, "sast", annotations[:600]); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if err := client.AddInsightAnnotations("PROJ", "widget", commit.ID, "sast", annotations[600:]); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if err := client.AddInsightAnnotations("PROJ", "widget", commit.ID, "sast", annotations[:1]); err == nil {
+		t.Fatalf("Want error for a report with more than 1000 annotations but got none\n")
+	}
+	if n := len(server.Annotations("PROJ", "widget", commit.ID, "sast")); n != 1000 {
+		t.Fatalf("Want 1000 annotations but got %d\n", n)
+	}
+
+	if err := client.DeleteInsightReport("PROJ", "widget", commit.ID, "sast"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if err := client.DeleteInsightReport("PROJ", "widget", commit.ID, "sast"); !stash.IsNotFound(err) {
+		t.Fatalf("Want not found error but got %v\n", err)
+	}
+}
@@ -0,0 +1,55 @@
+package stashtest
+
+import (
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestMerge(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	pr, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	server.VetoMerge("PROJ", "widget", pr.ID, stash.MergeVeto{SummaryMessage: "Not enough approvals"})
+
+	status, err := client.CanMerge("PROJ", "widget", pr.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if status.CanMerge || len(status.Vetoes) != 1 {
+		t.Fatalf("Want one veto but got %+v\n", status)
+	}
+	if _, err := client.MergePullRequest("PROJ", "widget", pr.ID, pr.Version, "", ""); !stash.IsMergeVetoed(err) {
+		t.Fatalf("Want vetoed merge but got %v\n", err)
+	}
+}
+
+func TestMergeUpdatesTarget(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	pr, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	merged, err := client.MergePullRequest("PROJ", "widget", pr.ID, pr.Version, stash.MergeNoFastForward, "")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if merged.State != "MERGED" {
+		t.Fatalf("Want MERGED but got %s\n", merged.State)
+	}
+	data, err := client.GetRawFile("PROJ", "widget", "docs/usage.md", "master")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if string(data) != "usage" {
+		t.Fatalf("Want usage but got %s\n", data)
+	}
+}
@@ -0,0 +1,64 @@
+package stashtest
+
+import (
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestParticipantStatus(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	pr, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", []string{"carol"})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	if _, err := client.ApprovePullRequest("PROJ", "widget", pr.ID); err == nil {
+		t.Fatalf("Want error approving one's own pull request but got none\n")
+	}
+	participant, err := client.SetParticipantStatus("PROJ", "widget", pr.ID, "carol", stash.StatusNeedsWork)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if participant.Role != "REVIEWER" || participant.Status != stash.StatusNeedsWork {
+		t.Fatalf("Want reviewer carol needing work but got %+v\n", participant)
+	}
+	if _, err := client.SetParticipantStatus("PROJ", "widget", pr.ID, "dave", stash.StatusApproved); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	updated, _ := server.PullRequest("PROJ", "widget", pr.ID)
+	if updated.Reviewers[0].Status != stash.StatusNeedsWork {
+		t.Fatalf("Want carol needing work but got %+v\n", updated.Reviewers)
+	}
+	if len(updated.Participants) != 1 || !updated.Participants[0].Approved {
+		t.Fatalf("Want dave approving but got %+v\n", updated.Participants)
+	}
+}
+
+func TestNeedsWorkMixedCaseUser(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+
+	pr, err := server.Client().CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", []string{"John.Doe@corp"})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	server.RequireBasicAuth("John.Doe@corp", "secret")
+	participant, err := server.Client().NeedsWorkPullRequest("PROJ", "widget", pr.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if participant.User.Slug != "john.doe_corp" || participant.Status != stash.StatusNeedsWork {
+		t.Fatalf("Want john.doe_corp needing work but got %+v\n", participant)
+	}
+
+	updated, _ := server.PullRequest("PROJ", "widget", pr.ID)
+	if updated.Reviewers[0].Status != stash.StatusNeedsWork {
+		t.Fatalf("Want John.Doe@corp needing work but got %+v\n", updated.Reviewers)
+	}
+}
@@ -0,0 +1,649 @@
+package stashtest
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xoom/stash"
+)
+
+type (
+	refResource struct {
+		ID         string `json:"id"`
+		Repository struct {
+			Slug    string `json:"slug"`
+			Project struct {
+				Key string `json:"key"`
+			} `json:"project"`
+		} `json:"repository"`
+	}
+
+	pullRequestResource struct {
+		Version     int              `json:"version"`
+		Title       string           `json:"title"`
+		Description string           `json:"description"`
+		FromRef     *refResource     `json:"fromRef"`
+		ToRef       *refResource     `json:"toRef"`
+		Reviewers   []stash.Reviewer `json:"reviewers"`
+	}
+)
+
+func (s *Server) registerPullRequestRoutes() {
+	const pr = "/rest/api/1.0/projects/{project}/repos/{repo}/pull-requests"
+	s.handle("GET", pr, s.listPullRequests)
+	s.handle("POST", pr, s.createPullRequest)
+	s.handle("GET", pr+"/{id}", s.getPullRequest)
+	s.handle("PUT", pr+"/{id}", s.updatePullRequest)
+	s.handle("DELETE", pr+"/{id}", s.deletePullRequest)
+	s.handle("POST", pr+"/{id}/decline", s.declinePullRequest)
+	s.handle("POST", pr+"/{id}/reopen", s.reopenPullRequest)
+	s.handle("GET", pr+"/{id}/merge", s.canMerge)
+	s.handle("POST", pr+"/{id}/merge", s.mergePullRequest)
+	s.handle("POST", pr+"/{id}/approve", s.approve)
+	s.handle("DELETE", pr+"/{id}/approve", s.approve)
+	s.handle("PUT", pr+"/{id}/participants/{user}", s.setParticipantStatus)
+	s.handle("GET", pr+"/{id}/changes", s.listChanges)
+	s.handle("GET", pr+"/{id}/commits", s.listPullRequestCommits)
+	s.handle("GET", pr+"/{id}/diff", s.getDiff)
+	s.handle("GET", pr+"/{id}/diff/{path...}", s.getDiff)
+	s.handle("GET", pr+"/{id}/activities", s.listActivities)
+	s.handle("GET", pr+"/{id}/comments", s.listComments)
+	s.handle("POST", pr+"/{id}/comments", s.createComment)
+	s.handle("PUT", pr+"/{id}/comments/{comment}", s.updateComment)
+	s.handle("DELETE", pr+"/{id}/comments/{comment}", s.deleteComment)
+	s.handle("GET", pr+"/{id}/tasks", s.listTasks)
+	s.handle("POST", "/rest/api/1.0/tasks", s.createTask)
+	s.handle("PUT", "/rest/api/1.0/tasks/{task}", s.updateTask)
+}
+
+// listPullRequests supports the state, at, direction, order, filterText,
+// role.N/username.N and withProperties parameters.
+func (s *Server) listPullRequests(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	state := strings.ToUpper(query.Get("state"))
+	if state == "" {
+		state = "OPEN"
+	}
+	at := strings.TrimPrefix(query.Get("at"), "refs/heads/")
+	outgoing := strings.ToUpper(query.Get("direction")) == "OUTGOING"
+	filterText := strings.ToLower(query.Get("filterText"))
+
+	var values []interface{}
+	for i := range repo.pullRequests {
+		pr := repo.pullRequests[len(repo.pullRequests)-1-i]
+		if strings.ToUpper(query.Get("order")) == "OLDEST" {
+			pr = repo.pullRequests[i]
+		}
+		if state != "ALL" && pr.State != state {
+			continue
+		}
+		branch := pr.ToRef.DisplayID
+		if outgoing {
+			branch = pr.FromRef.DisplayID
+		}
+		if at != "" && branch != at {
+			continue
+		}
+		if filterText != "" && !strings.Contains(strings.ToLower(pr.Title+" "+pr.Description), filterText) {
+			continue
+		}
+		if !pr.hasParticipants(query) {
+			continue
+		}
+		view := pr.view()
+		if query.Get("withProperties") == "false" {
+			view.Properties = stash.PullRequestProperties{}
+		}
+		values = append(values, view)
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) createPullRequest(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	var body pullRequestResource
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.Title == "" || body.FromRef == nil || body.ToRef == nil {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "A pull request needs a title, a fromRef and a toRef.")
+		return
+	}
+
+	from, fromBranch, ok := s.resolveRef(w, repo, body.FromRef)
+	if !ok {
+		return
+	}
+	to, toBranch, ok := s.resolveRef(w, repo, body.ToRef)
+	if !ok {
+		return
+	}
+	if from == to && fromBranch == toBranch {
+		writeError(w, http.StatusConflict, "com.atlassian.bitbucket.pull.EmptyPullRequestException", "The source and target refs are the same.")
+		return
+	}
+	for _, existing := range repo.pullRequests {
+		if existing.State == "OPEN" && existing.from == from && existing.FromRef.DisplayID == fromBranch && existing.ToRef.DisplayID == toBranch {
+			writeError(w, http.StatusConflict, "com.atlassian.bitbucket.pull.DuplicatePullRequestException", fmt.Sprintf("Only one pull request may be open for a given source and target branch (#%d).", existing.ID))
+			return
+		}
+	}
+
+	created := now()
+	pr := &pullRequest{
+		PullRequest: stash.PullRequest{
+			ID:          repo.nextPullRequestID(),
+			State:       "OPEN",
+			Open:        true,
+			Title:       body.Title,
+			Description: body.Description,
+			FromRef:     newRef(from, fromBranch),
+			ToRef:       newRef(to, toBranch),
+			CreatedDate: created,
+			UpdatedDate: created,
+			Reviewers:   s.reviewers(body.Reviewers),
+			Author:      stash.Author{User: s.currentUser(r), Role: "AUTHOR"},
+		},
+		from: from,
+		to:   to,
+	}
+	pr.setLinks(s, repo)
+	repo.pullRequests = append(repo.pullRequests, pr)
+	s.addActivity(pr, r, stash.Activity{Action: stash.ActivityOpened}, 0)
+	writeJSON(w, http.StatusCreated, pr.view())
+}
+
+func (s *Server) getPullRequest(w http.ResponseWriter, r *http.Request, p params) {
+	// The raw diff lives at {id}.diff, which routes here.
+	if id := strings.TrimSuffix(p["id"], ".diff"); id != p["id"] {
+		p["id"] = id
+		s.getRawDiff(w, r, p)
+		return
+	}
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, pr.view())
+}
+
+func (s *Server) updatePullRequest(w http.ResponseWriter, r *http.Request, p params) {
+	repo, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	var body pullRequestResource
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if !checkVersion(w, pr, body.Version) || !checkOpen(w, pr) {
+		return
+	}
+
+	if body.ToRef != nil {
+		to, toBranch, ok := s.resolveRef(w, repo, body.ToRef)
+		if !ok {
+			return
+		}
+		if to == pr.from && toBranch == pr.FromRef.DisplayID {
+			writeError(w, http.StatusConflict, "com.atlassian.bitbucket.pull.EmptyPullRequestException", "The source and target refs are the same.")
+			return
+		}
+		pr.to, pr.ToRef = to, newRef(to, toBranch)
+	}
+	if body.Title != "" {
+		pr.Title = body.Title
+	}
+	if body.Description != "" {
+		pr.Description = body.Description
+	}
+	update := stash.Activity{Action: stash.ActivityUpdated}
+	if body.Reviewers != nil {
+		reviewers := s.reviewers(body.Reviewers)
+		update.AddedReviewers = reviewerDifference(reviewers, pr.Reviewers)
+		update.RemovedReviewers = reviewerDifference(pr.Reviewers, reviewers)
+		pr.Reviewers = reviewers
+	}
+	pr.touch()
+	s.addActivity(pr, r, update, 0)
+	writeJSON(w, http.StatusOK, pr.view())
+}
+
+func (s *Server) declinePullRequest(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	version, _ := strconv.Atoi(r.URL.Query().Get("version"))
+	if !checkVersion(w, pr, version) || !checkOpen(w, pr) {
+		return
+	}
+	pr.State, pr.Open, pr.Closed = "DECLINED", false, true
+	pr.touch()
+	s.addActivity(pr, r, stash.Activity{Action: stash.ActivityDeclined}, 0)
+	writeJSON(w, http.StatusOK, pr.view())
+}
+
+// reopenPullRequest reopens a declined pull request unless another one is
+// already open between the same branches.
+func (s *Server) reopenPullRequest(w http.ResponseWriter, r *http.Request, p params) {
+	repo, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	version, _ := strconv.Atoi(r.URL.Query().Get("version"))
+	if !checkVersion(w, pr, version) {
+		return
+	}
+	if pr.State != "DECLINED" {
+		writeError(w, http.StatusConflict, "com.atlassian.bitbucket.pull.IllegalPullRequestStateException", fmt.Sprintf("Only declined pull requests can be reopened; this one is %s.", strings.ToLower(pr.State)))
+		return
+	}
+	for _, existing := range repo.pullRequests {
+		if existing.State == "OPEN" && existing.from == pr.from && existing.FromRef.DisplayID == pr.FromRef.DisplayID && existing.ToRef.DisplayID == pr.ToRef.DisplayID {
+			writeError(w, http.StatusConflict, "com.atlassian.bitbucket.pull.DuplicatePullRequestException", fmt.Sprintf("Only one pull request may be open for a given source and target branch (#%d).", existing.ID))
+			return
+		}
+	}
+	pr.State, pr.Open, pr.Closed = "OPEN", true, false
+	pr.touch()
+	s.addActivity(pr, r, stash.Activity{Action: stash.ActivityReopened}, 0)
+	writeJSON(w, http.StatusOK, pr.view())
+}
+
+func (s *Server) deletePullRequest(w http.ResponseWriter, r *http.Request, p params) {
+	repo, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	var body pullRequestResource
+	if !decodeBody(w, r, &body) || !checkVersion(w, pr, body.Version) {
+		return
+	}
+	for i, other := range repo.pullRequests {
+		if other == pr {
+			repo.pullRequests = append(repo.pullRequests[:i], repo.pullRequests[i+1:]...)
+			break
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) canMerge(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok || !checkOpen(w, pr) {
+		return
+	}
+	writeJSON(w, http.StatusOK, stash.MergeStatus{
+		CanMerge: len(pr.vetoes) == 0,
+		Outcome:  "CLEAN",
+		Vetoes:   append([]stash.MergeVeto{}, pr.vetoes...),
+	})
+}
+
+// mergePullRequest brings the files of the source branch onto the target branch
+// with a merge commit.
+func (s *Server) mergePullRequest(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	version, _ := strconv.Atoi(r.URL.Query().Get("version"))
+	if !checkVersion(w, pr, version) || !checkOpen(w, pr) {
+		return
+	}
+	if len(pr.vetoes) > 0 {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"errors": []stash.ErrorDetail{{
+				Message:       "Merging the pull request has been vetoed.",
+				ExceptionName: "com.atlassian.bitbucket.pull.PullRequestMergeVetoedException",
+				Vetoes:        pr.vetoes,
+			}},
+		})
+		return
+	}
+
+	merge := stash.Commit{
+		Message: fmt.Sprintf("Merge pull request #%d in %s/%s from %s to %s", pr.ID, pr.to.Project.Key, pr.to.Slug, pr.FromRef.DisplayID, pr.ToRef.DisplayID),
+	}
+	if source := pr.from.head(pr.FromRef.DisplayID); source != "" {
+		merge.Parents = []stash.CommitParent{{ID: source, DisplayID: shortID(source)}}
+	}
+	commit := s.addCommit(pr.to, pr.ToRef.DisplayID, merge)
+	target := pr.to.files[pr.ToRef.DisplayID]
+	for path, content := range pr.from.files[pr.FromRef.DisplayID] {
+		target[path] = content
+	}
+	pr.State, pr.Open, pr.Closed = "MERGED", false, true
+	pr.touch()
+	s.addActivity(pr, r, stash.Activity{Action: stash.ActivityMerged, Commit: &commit}, 0)
+	writeJSON(w, http.StatusOK, pr.view())
+}
+
+func (s *Server) approve(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok || !checkOpen(w, pr) {
+		return
+	}
+	status := stash.StatusApproved
+	if r.Method == "DELETE" {
+		status = stash.StatusUnapproved
+	}
+	s.writeParticipant(w, r, pr, s.currentUser(r), status)
+}
+
+func (s *Server) setParticipantStatus(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok || !checkOpen(w, pr) {
+		return
+	}
+	var body struct {
+		Status string `json:"status"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	switch body.Status {
+	case stash.StatusApproved, stash.StatusUnapproved, stash.StatusNeedsWork:
+	default:
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", fmt.Sprintf("Invalid participant status %q.", body.Status))
+		return
+	}
+	user := newUser(p["user"])
+	for _, known := range pr.users() {
+		if known.Slug == p["user"] {
+			user = known
+		}
+	}
+	s.writeParticipant(w, r, pr, user, body.Status)
+}
+
+// writeParticipant sets the status of user, adding them as a participant when
+// they are not a reviewer.
+func (s *Server) writeParticipant(w http.ResponseWriter, r *http.Request, pr *pullRequest, user stash.User, status string) {
+	if user.Name == pr.Author.User.Name {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.pull.InvalidPullRequestParticipantException", "Authors cannot review their own pull requests.")
+		return
+	}
+	action := map[string]string{
+		stash.StatusApproved:   stash.ActivityApproved,
+		stash.StatusUnapproved: stash.ActivityUnapproved,
+		stash.StatusNeedsWork:  stash.ActivityReviewed,
+	}[status]
+	s.addActivity(pr, r, stash.Activity{Action: action, User: user}, 0)
+
+	participant := stash.Participant{User: user, Role: "REVIEWER", Approved: status == stash.StatusApproved, Status: status}
+	for i := range pr.Reviewers {
+		if pr.Reviewers[i].User.Name == user.Name {
+			pr.Reviewers[i].Status = status
+			writeJSON(w, http.StatusOK, participant)
+			return
+		}
+	}
+
+	participant.Role = "PARTICIPANT"
+	for i := range pr.Participants {
+		if pr.Participants[i].User.Name == user.Name {
+			pr.Participants[i] = participant
+			writeJSON(w, http.StatusOK, participant)
+			return
+		}
+	}
+	pr.Participants = append(pr.Participants, participant)
+	writeJSON(w, http.StatusOK, participant)
+}
+
+// listChanges compares the files of the source branch with those of the target.
+func (s *Server) listChanges(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	writePage(w, r, changes(pr.to.files[pr.ToRef.DisplayID], pr.from.files[pr.FromRef.DisplayID]))
+}
+
+// listPullRequestCommits returns the commits of the source branch that the
+// target branch lacks, newest first.
+func (s *Server) listPullRequestCommits(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	var values []interface{}
+	for _, commit := range pr.commits() {
+		values = append(values, commit)
+	}
+	writePage(w, r, values)
+}
+
+// pullRequest resolves the {project}, {repo} and {id} parameters, answering 404
+// itself when there is no such pull request.
+func (s *Server) pullRequest(w http.ResponseWriter, p params) (*repository, *pullRequest, bool) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return nil, nil, false
+	}
+	id, _ := strconv.Atoi(p["id"])
+	pr := repo.pullRequest(id)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.pull.NoSuchPullRequestException", fmt.Sprintf("No pull request exists with ID %s for this repository.", p["id"]))
+		return nil, nil, false
+	}
+	return repo, pr, true
+}
+
+// resolveRef finds the repository and branch a ref of a request body points to.
+// Refs without a repository belong to repo.
+func (s *Server) resolveRef(w http.ResponseWriter, repo *repository, ref *refResource) (*repository, string, bool) {
+	target := repo
+	if ref.Repository.Slug != "" {
+		if target = s.lookup(ref.Repository.Project.Key, ref.Repository.Slug); target == nil {
+			writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.repository.NoSuchRepositoryException", fmt.Sprintf("Repository %s/%s does not exist.", ref.Repository.Project.Key, ref.Repository.Slug))
+			return nil, "", false
+		}
+	}
+	branch := strings.TrimPrefix(ref.ID, "refs/heads/")
+	if _, ok := target.branches[branch]; !ok {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.repository.NoSuchBranchException", fmt.Sprintf("Repository \"%s\" of project with key \"%s\" has no branch \"%s\"", target.Slug, target.Project.Key, branch))
+		return nil, "", false
+	}
+	return target, branch, true
+}
+
+func (s *Server) reviewers(reviewers []stash.Reviewer) []stash.Reviewer {
+	var users []stash.Reviewer
+	for _, reviewer := range reviewers {
+		name := reviewer.User.Name
+		users = append(users, stash.Reviewer{
+			User:   newUser(name),
+			Status: "UNAPPROVED",
+		})
+	}
+	return users
+}
+
+// checkVersion answers 409 like Stash does when an update is based on an out of
+// date version of the pull request.
+func checkVersion(w http.ResponseWriter, pr *pullRequest, version int) bool {
+	if version == pr.Version {
+		return true
+	}
+	writeOutOfDate(w, "com.atlassian.bitbucket.pull.PullRequestOutOfDateException", "You are attempting to modify a pull request based on out-of-date information.", pr.Version, version)
+	return false
+}
+
+func writeOutOfDate(w http.ResponseWriter, exceptionName, message string, current, expected int) {
+	writeJSON(w, http.StatusConflict, map[string]interface{}{
+		"errors": []stash.ErrorDetail{{
+			Message:         message,
+			ExceptionName:   exceptionName,
+			CurrentVersion:  &current,
+			ExpectedVersion: &expected,
+		}},
+	})
+}
+
+func checkOpen(w http.ResponseWriter, pr *pullRequest) bool {
+	if pr.State == "OPEN" {
+		return true
+	}
+	writeError(w, http.StatusConflict, "com.atlassian.bitbucket.pull.IllegalPullRequestStateException", fmt.Sprintf("The pull request is %s.", strings.ToLower(pr.State)))
+	return false
+}
+
+// newRef returns the ref of branch in repo.  Its latest commit is filled in
+// by view.
+func newRef(repo *repository, branch string) stash.Ref {
+	return stash.Ref{
+		ID:        "refs/heads/" + branch,
+		DisplayID: branch,
+		Repository: stash.PullRequestRepository{
+			Slug:    repo.Slug,
+			Name:    repo.Name,
+			Project: stash.PullRequestProject{Key: repo.Project.Key},
+		},
+	}
+}
+
+// newPath splits p the way Stash does.
+func newPath(p string) stash.Path {
+	components := strings.Split(p, "/")
+	name := components[len(components)-1]
+	path := stash.Path{
+		Components: components,
+		Parent:     strings.Join(components[:len(components)-1], "/"),
+		Name:       name,
+		ToString:   p,
+	}
+	if i := strings.LastIndex(name, "."); i > 0 {
+		path.Extension = name[i+1:]
+	}
+	return path
+}
+
+// blobID returns the git object ID of a file with content.
+func blobID(content string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(content), content))))
+}
+
+// changes lists how the files of after differ from those of before.
+func changes(before, after map[string]string) []interface{} {
+	var values []interface{}
+	for _, path := range changedPaths(before, after) {
+		change := stash.Change{Type: stash.ChangeModify, NodeType: stash.NodeFile, Path: newPath(path)}
+		old, inBefore := before[path]
+		current, inAfter := after[path]
+		if inAfter {
+			change.ContentID = blobID(current)
+		}
+		if inBefore {
+			change.FromContentID = blobID(old)
+		}
+		switch {
+		case !inBefore:
+			change.Type = stash.ChangeAdd
+		case !inAfter:
+			change.Type = stash.ChangeDelete
+		}
+		values = append(values, change)
+	}
+	return values
+}
+
+// changedPaths returns the sorted paths whose content differs between a and b.
+func changedPaths(a, b map[string]string) []string {
+	var paths []string
+	for path, content := range a {
+		if other, ok := b[path]; !ok || other != content {
+			paths = append(paths, path)
+		}
+	}
+	for path := range b {
+		if _, ok := a[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// commits walks back from the source branch head until it reaches history the
+// target branch already has.
+func (pr *pullRequest) commits() []stash.Commit {
+	return commitsBetween(pr.from, pr.from.head(pr.FromRef.DisplayID), pr.to, pr.to.head(pr.ToRef.DisplayID))
+}
+
+// users returns the author, the reviewers and the participants of pr.
+func (pr *pullRequest) users() []stash.User {
+	users := []stash.User{pr.Author.User}
+	for _, reviewer := range pr.Reviewers {
+		users = append(users, reviewer.User)
+	}
+	for _, participant := range pr.Participants {
+		users = append(users, participant.User)
+	}
+	return users
+}
+
+// hasParticipants tells whether pr matches the role.N and username.N filters.
+func (pr *pullRequest) hasParticipants(query url.Values) bool {
+	for n := 1; query.Get("username."+strconv.Itoa(n)) != ""; n++ {
+		user := query.Get("username." + strconv.Itoa(n))
+		found := false
+		switch strings.ToUpper(query.Get("role." + strconv.Itoa(n))) {
+		case "AUTHOR":
+			found = pr.Author.User.Name == user
+		case "REVIEWER":
+			for _, reviewer := range pr.Reviewers {
+				found = found || reviewer.User.Name == user
+			}
+		default:
+			for _, participant := range pr.Participants {
+				found = found || participant.User.Name == user
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// view returns the pull request as Stash renders it, with its counts.
+func (pr *pullRequest) view() stash.PullRequest {
+	v := pr.PullRequest
+	v.FromRef.LatestCommit = pr.from.head(pr.FromRef.DisplayID)
+	v.ToRef.LatestCommit = pr.to.head(pr.ToRef.DisplayID)
+	v.Properties = stash.PullRequestProperties{CommentCount: len(pr.comments)}
+	for _, task := range pr.tasks {
+		if task.State == stash.TaskOpen {
+			v.Properties.OpenTaskCount++
+		} else {
+			v.Properties.ResolvedTaskCount++
+		}
+	}
+	return v
+}
+
+func (pr *pullRequest) touch() {
+	pr.Version++
+	pr.UpdatedDate = now()
+}
+
+func (pr *pullRequest) setLinks(s *Server, repo *repository) {
+	pr.Links.Self = append(pr.Links.Self[:0], struct {
+		Href string `json:"href"`
+	}{Href: fmt.Sprintf("%s/projects/%s/repos/%s/pull-requests/%d", s.URL, repo.Project.Key, repo.Slug, pr.ID)})
+}
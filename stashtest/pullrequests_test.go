@@ -0,0 +1,132 @@
+package stashtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestPullRequestFilters(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	server.AddBranch("PROJ", "widget", "release/1.0", "master")
+	server.AddBranch("PROJ", "widget", "hotfix", "master")
+	server.AddFile("PROJ", "widget", "hotfix", "README.md", "widget, fixed")
+	client := server.Client()
+	alice := stash.NewClient("alice", "", server.BaseURL())
+
+	if _, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", []string{"bob"}); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := alice.CreatePullRequest("PROJ", "widget", "Fix readme", "", "hotfix", "release/1.0", nil); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	var tests = []struct {
+		options stash.PullRequestOptions
+		want    string
+	}{
+		{stash.PullRequestOptions{}, "[2 1]"},
+		{stash.PullRequestOptions{Order: stash.OrderOldest}, "[1 2]"},
+		{stash.PullRequestOptions{At: "refs/heads/release/1.0"}, "[2]"},
+		{stash.PullRequestOptions{At: "refs/heads/feature/readme", Direction: stash.DirectionOutgoing}, "[1]"},
+		{stash.PullRequestOptions{FilterText: "FIX"}, "[2]"},
+		{stash.PullRequestOptions{Author: "alice"}, "[2]"},
+		{stash.PullRequestOptions{Reviewer: "bob"}, "[1]"},
+		{stash.PullRequestOptions{State: stash.PullRequestMerged}, "[]"},
+	}
+	for testNumber, test := range tests {
+		pullRequests, err := client.GetPullRequestsWithOptions("PROJ", "widget", test.options)
+		if err != nil {
+			t.Fatalf("Test %d: not expecting error: %v\n", testNumber, err)
+		}
+		var ids []int
+		for _, pr := range pullRequests {
+			ids = append(ids, pr.ID)
+		}
+		if fmt.Sprint(ids) != test.want {
+			t.Fatalf("Test %d: want %s but got %v\n", testNumber, test.want, ids)
+		}
+	}
+}
+
+func TestForkPullRequests(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	server.AddProject("~ALICE")
+	server.AddRepository("~ALICE", "widget")
+	fix := server.AddCommit("~ALICE", "widget", "fix", stash.Commit{})
+	server.AddFile("~ALICE", "widget", "fix", "README.md", "widget, fixed")
+	client := server.Client()
+
+	from := stash.PullRequestRef{Id: "refs/heads/fix", Repository: stash.PullRequestRepository{Slug: "widget", Project: stash.PullRequestProject{Key: "~ALICE"}}}
+	to := stash.PullRequestRef{Id: "refs/heads/master", Repository: stash.PullRequestRepository{Slug: "widget", Project: stash.PullRequestProject{Key: "PROJ"}}}
+	pr, err := client.CreateCrossRepositoryPullRequest("Fix readme", "", from, to, nil)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if pr.FromRef.Repository.Project.Key != "~ALICE" || pr.FromRef.LatestCommit != fix.ID || pr.ToRef.Repository.Project.Key != "PROJ" {
+		t.Fatalf("Want a pull request from ~ALICE/widget into PROJ/widget but got %+v\n", pr)
+	}
+
+	pullRequests, err := client.GetPullRequests("PROJ", "widget", "OPEN")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(pullRequests) != 1 || pullRequests[0].FromRef.ID != "refs/heads/fix" || pullRequests[0].FromRef.Repository.Project.Key != "~ALICE" {
+		t.Fatalf("Want the fork pull request but got %+v\n", pullRequests)
+	}
+	changes, err := client.GetPullRequestChangedPaths("PROJ", "widget", pr.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if fmt.Sprint(changes) != "[README.md]" {
+		t.Fatalf("Want README.md but got %v\n", changes)
+	}
+}
+
+func TestReopenAndDelete(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	pr, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.ReopenPullRequest("PROJ", "widget", pr.ID, pr.Version); !stash.IsConflict(err) {
+		t.Fatalf("Want conflict reopening an open pull request but got %v\n", err)
+	}
+	if err := client.DeclinePullRequest("PROJ", "widget", pr.ID, pr.Version); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.ReopenPullRequest("PROJ", "widget", pr.ID, pr.Version); !stash.IsStaleVersion(err) {
+		t.Fatalf("Want stale version error but got %v\n", err)
+	}
+	declined, _ := server.PullRequest("PROJ", "widget", pr.ID)
+	reopened, err := client.ReopenPullRequest("PROJ", "widget", pr.ID, declined.Version)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if reopened.State != "OPEN" || reopened.Version != declined.Version+1 {
+		t.Fatalf("Want an open pull request at version %d but got %+v\n", declined.Version+1, reopened)
+	}
+
+	if err := client.DeletePullRequest("PROJ", "widget", pr.ID, declined.Version); !stash.IsStaleVersion(err) {
+		t.Fatalf("Want stale version error but got %v\n", err)
+	}
+	if err := client.DeletePullRequest("PROJ", "widget", pr.ID, reopened.Version); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.GetPullRequest("PROJ", "widget", "1"); !stash.IsNotFound(err) {
+		t.Fatalf("Want not found error but got %v\n", err)
+	}
+	again, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if again.ID != 2 {
+		t.Fatalf("Want a new ID after a deletion but got %d\n", again.ID)
+	}
+}
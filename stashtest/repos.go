@@ -0,0 +1,376 @@
+package stashtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/xoom/stash"
+)
+
+func (s *Server) registerRoutes() {
+	s.handle("GET", "/rest/api/1.0/repos", s.listRepositories)
+	s.handle("GET", "/rest/api/1.0/profile/recent/repos", s.listRepositories)
+	s.handle("POST", "/rest/api/1.0/projects/{project}/repos", s.createRepository)
+	s.handle("GET", "/rest/api/1.0/projects/{project}/repos/{repo}", s.getRepository)
+	s.handle("GET", "/rest/api/1.0/projects/{project}/repos/{repo}/branches", s.listBranches)
+	s.handle("GET", "/rest/api/1.0/projects/{project}/repos/{repo}/tags", s.listTags)
+	s.handle("GET", "/rest/api/1.0/projects/{project}/repos/{repo}/commits", s.listCommits)
+	s.handle("GET", "/rest/api/1.0/projects/{project}/repos/{repo}/commits/{commit}", s.getCommit)
+	s.handle("DELETE", "/rest/branch-utils/1.0/projects/{project}/repos/{repo}/branches", s.deleteBranch)
+	s.handle("GET", "/rest/branch-permissions/1.0/projects/{project}/repos/{repo}/restricted", s.listRestrictions)
+	s.handle("POST", "/rest/branch-permissions/1.0/projects/{project}/repos/{repo}/restricted", s.createRestriction)
+	s.handle("DELETE", "/rest/branch-permissions/1.0/projects/{project}/repos/{repo}/restricted/{id}", s.deleteRestriction)
+	s.handle("GET", "/projects/{project}/repos/{repo}/browse/{path...}", s.rawFile)
+	s.handle("GET", "/plugins/servlet/applinks/whoami", s.whoami)
+	s.handle("GET", "/rest/api/1.0/users", s.listUsers)
+	s.registerPullRequestRoutes()
+	s.registerDefaultReviewerRoutes()
+	s.registerBuildStatusRoutes()
+	s.registerInsightRoutes()
+	s.registerCompareRoutes()
+	s.registerCommitRoutes()
+}
+
+func (s *Server) listRepositories(w http.ResponseWriter, r *http.Request, p params) {
+	var values []interface{}
+	for _, repo := range s.sortedRepositories() {
+		values = append(values, repo.Repository)
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) createRepository(w http.ResponseWriter, r *http.Request, p params) {
+	proj, ok := s.projects[strings.ToUpper(p["project"])]
+	if !ok {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.project.NoSuchProjectException", fmt.Sprintf("Project %s does not exist.", p["project"]))
+		return
+	}
+
+	var body struct {
+		Name  string `json:"name"`
+		ScmID string `json:"scmId"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.Name == "" {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "The repository name is required.")
+		return
+	}
+	if s.lookup(proj.key, strings.ToLower(strings.Replace(body.Name, " ", "-", -1))) != nil {
+		writeError(w, http.StatusConflict, "com.atlassian.bitbucket.repository.DuplicateRepositoryNameException", "This repository name is already taken.")
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.addRepository(proj, body.Name).Repository)
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, repo.Repository)
+}
+
+func (s *Server) listBranches(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	var names []string
+	for name := range repo.branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var values []interface{}
+	for _, name := range names {
+		values = append(values, *repo.branches[name])
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	var names []string
+	for name := range repo.tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var values []interface{}
+	for _, name := range names {
+		values = append(values, *repo.tags[name])
+	}
+	writePage(w, r, values)
+}
+
+// listCommits returns the commits after since up to and including until, newest
+// first.  History is linear: commits are ordered as they were added, and only
+// merge commits have a second parent.
+func (s *Server) listCommits(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	until := len(repo.commits) - 1
+	if ref := query.Get("until"); ref != "" {
+		if until = repo.commitIndex(ref); until < 0 {
+			missingCommit(w, r, repo, ref)
+			return
+		}
+	}
+	since := -1
+	if ref := query.Get("since"); ref != "" {
+		if since = repo.commitIndex(ref); since < 0 {
+			missingCommit(w, r, repo, ref)
+			return
+		}
+	}
+
+	var values []interface{}
+	for i := until; i > since; i-- {
+		commit := repo.commits[i]
+		merge := len(commit.Parents) > 1
+		if (query.Get("merges") == "exclude" && merge) || (query.Get("merges") == "only" && !merge) {
+			continue
+		}
+		if path := query.Get("path"); path != "" && !repo.touches(commit, path) {
+			continue
+		}
+		values = append(values, commit)
+	}
+	writePage(w, r, values)
+}
+
+// missingCommit answers an unknown since or until, with an empty page when
+// ignoreMissing is set.
+func missingCommit(w http.ResponseWriter, r *http.Request, repo *repository, ref string) {
+	if r.URL.Query().Get("ignoreMissing") == "true" {
+		writePage(w, r, nil)
+		return
+	}
+	writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.commit.NoSuchCommitException", fmt.Sprintf("Commit '%s' does not exist in repository '%s'.", ref, repo.Slug))
+}
+
+// touches tells whether commit changed path, a file or a directory.
+func (repo *repository) touches(commit stash.Commit, path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, changed := range changedPaths(repo.parentTree(commit), repo.tree(commit.ID)) {
+		if changed == path || strings.HasPrefix(changed, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) getCommit(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	i := repo.commitIndex(p["commit"])
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.commit.NoSuchCommitException", fmt.Sprintf("Commit '%s' does not exist in repository '%s'.", p["commit"], repo.Slug))
+		return
+	}
+	writeJSON(w, http.StatusOK, repo.commits[i])
+}
+
+// head returns the latest commit of branch, or "" once it is deleted.
+func (repo *repository) head(branch string) string {
+	if b, ok := repo.branches[branch]; ok {
+		return b.LatestChangeSet
+	}
+	return ""
+}
+
+// commitIndex resolves a commit ID, a unique ID prefix, a branch or a tag to the
+// index of the commit in repo.commits, or -1.
+func (repo *repository) commitIndex(ref string) int {
+	if branch, ok := repo.branches[strings.TrimPrefix(ref, "refs/heads/")]; ok {
+		ref = branch.LatestChangeSet
+	} else if tag, ok := repo.tags[strings.TrimPrefix(ref, "refs/tags/")]; ok {
+		ref = tag.Hash
+	}
+	for i, commit := range repo.commits {
+		if ref != "" && strings.HasPrefix(commit.ID, ref) {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolve returns the ID of the commit ref refers to, as commitIndex takes it.
+func (repo *repository) resolve(ref string) (string, bool) {
+	i := repo.commitIndex(ref)
+	if i < 0 {
+		return "", false
+	}
+	return repo.commits[i].ID, true
+}
+
+// tree returns the files at ref.  Files belong to the latest commit of a
+// branch, so a commit has the files its branch had when it stopped being the
+// latest.
+func (repo *repository) tree(ref string) map[string]string {
+	if _, ok := repo.branches[strings.TrimPrefix(ref, "refs/heads/")]; ok {
+		return repo.files[strings.TrimPrefix(ref, "refs/heads/")]
+	}
+	id, ok := repo.resolve(ref)
+	if !ok {
+		return nil
+	}
+	if files, ok := repo.trees[id]; ok {
+		return files
+	}
+	var names []string
+	for name, branch := range repo.branches {
+		if branch.LatestChangeSet == id {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return nil
+	}
+	return repo.files[names[0]]
+}
+
+func (s *Server) deleteBranch(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	var body struct {
+		Name   string `json:"name"`
+		DryRun bool   `json:"dryRun"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	name := strings.TrimPrefix(body.Name, "refs/heads/")
+	if _, ok := repo.branches[name]; !ok {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.repository.NoSuchBranchException", fmt.Sprintf("Branch %s does not exist.", name))
+		return
+	}
+	if !body.DryRun {
+		delete(repo.branches, name)
+		delete(repo.files, name)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listRestrictions(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	var values []interface{}
+	for _, restriction := range repo.restrictions {
+		values = append(values, restriction)
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) createRestriction(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	var body stash.BranchPermission
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	for _, restriction := range repo.restrictions {
+		if restriction.Branch.DisplayID == body.Branch {
+			writeError(w, http.StatusConflict, "", fmt.Sprintf("Branch %s is already restricted.", body.Branch))
+			return
+		}
+	}
+
+	s.nextRestrictionID++
+	restriction := stash.BranchRestriction{
+		Id:     s.nextRestrictionID,
+		Branch: stash.Branch{ID: "refs/heads/" + body.Branch, DisplayID: body.Branch},
+	}
+	repo.restrictions = append(repo.restrictions, restriction)
+	writeJSON(w, http.StatusOK, restriction)
+}
+
+func (s *Server) deleteRestriction(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	for i, restriction := range repo.restrictions {
+		if fmt.Sprint(restriction.Id) == p["id"] {
+			repo.restrictions = append(repo.restrictions[:i], repo.restrictions[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "", fmt.Sprintf("No branch restriction %s.", p["id"]))
+}
+
+func (s *Server) rawFile(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	branch := strings.TrimPrefix(r.URL.Query().Get("at"), "refs/heads/")
+	content, ok := repo.files[branch][p["path"]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.content.NoSuchPathException", fmt.Sprintf("The path \"%s\" does not exist at revision \"%s\"", p["path"], branch))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, content)
+}
+
+func (s *Server) whoami(w http.ResponseWriter, r *http.Request, p params) {
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, s.currentUser(r).Name)
+}
+
+// listUsers lists the requesting user and everyone taking part in a pull
+// request whose name, display name or e-mail address contains the filter
+// parameter, ignoring case.
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request, p params) {
+	users := map[string]stash.User{}
+	current := s.currentUser(r)
+	users[current.Slug] = current
+	for _, project := range s.projects {
+		for _, repo := range project.repositories {
+			for _, pr := range repo.pullRequests {
+				for _, user := range pr.users() {
+					users[user.Slug] = user
+				}
+			}
+		}
+	}
+
+	filter := strings.ToLower(r.URL.Query().Get("filter"))
+	var slugs []string
+	for slug, user := range users {
+		for _, field := range []string{user.Name, user.DisplayName, user.EmailAddress} {
+			if strings.Contains(strings.ToLower(field), filter) {
+				slugs = append(slugs, slug)
+				break
+			}
+		}
+	}
+	sort.Strings(slugs)
+	var values []interface{}
+	for _, slug := range slugs {
+		values = append(values, users[slug])
+	}
+	writePage(w, r, values)
+}
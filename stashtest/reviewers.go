@@ -0,0 +1,119 @@
+package stashtest
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/xoom/stash"
+)
+
+func (s *Server) registerDefaultReviewerRoutes() {
+	const project = "/rest/default-reviewers/1.0/projects/{project}"
+	s.handle("GET", project+"/conditions", s.listProjectConditions)
+	s.handle("GET", project+"/repos/{repo}/conditions", s.listConditions)
+	s.handle("GET", project+"/repos/{repo}/reviewers", s.listDefaultReviewers)
+}
+
+// AddDefaultReviewers adds a default reviewer condition to a repository or,
+// when slug is empty, to a project.  Matchers of type MatcherBranch,
+// MatcherPattern (a glob on the branch name) and MatcherAnyRef are honoured.
+func (s *Server) AddDefaultReviewers(projectKey, slug string, condition stash.DefaultReviewerCondition) stash.DefaultReviewerCondition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextConditionID++
+	condition.ID = s.nextConditionID
+	if slug == "" {
+		p, ok := s.projects[strings.ToUpper(projectKey)]
+		if !ok {
+			panic(fmt.Sprintf("stashtest: no project %s", projectKey))
+		}
+		condition.Scope = stash.Scope{Type: "PROJECT"}
+		p.conditions = append(p.conditions, condition)
+		return condition
+	}
+	repo := s.mustRepository(projectKey, slug)
+	condition.Scope = stash.Scope{Type: "REPOSITORY", ResourceID: repo.ID}
+	repo.conditions = append(repo.conditions, condition)
+	return condition
+}
+
+func (s *Server) listProjectConditions(w http.ResponseWriter, r *http.Request, p params) {
+	project, ok := s.projects[strings.ToUpper(p["project"])]
+	if !ok {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.project.NoSuchProjectException", fmt.Sprintf("Project %s does not exist.", p["project"]))
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(project.conditions))
+}
+
+// listConditions returns the conditions of the repository followed by those of
+// its project.
+func (s *Server) listConditions(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(s.conditions(repo)))
+}
+
+// listDefaultReviewers applies the conditions of the target repository to the
+// sourceRefId and targetRefId parameters, leaving out the current user.
+func (s *Server) listDefaultReviewers(w http.ResponseWriter, r *http.Request, p params) {
+	repo, ok := s.repository(w, p)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	if _, err := strconv.Atoi(query.Get("sourceRepoId")); err != nil || query.Get("sourceRefId") == "" || query.Get("targetRefId") == "" {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "sourceRepoId, targetRepoId, sourceRefId and targetRefId are required.")
+		return
+	}
+
+	current := s.currentUser(r)
+	users := []stash.User{}
+	seen := map[string]bool{current.Name: true}
+	for _, condition := range s.conditions(repo) {
+		if !refMatches(condition.SourceRefMatcher, query.Get("sourceRefId")) || !refMatches(condition.TargetRefMatcher, query.Get("targetRefId")) {
+			continue
+		}
+		for _, user := range condition.Reviewers {
+			if !seen[user.Name] {
+				seen[user.Name] = true
+				users = append(users, user)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) conditions(repo *repository) []stash.DefaultReviewerCondition {
+	conditions := append([]stash.DefaultReviewerCondition(nil), repo.conditions...)
+	if p, ok := s.projects[strings.ToUpper(repo.Project.Key)]; ok {
+		conditions = append(conditions, p.conditions...)
+	}
+	return conditions
+}
+
+func refMatches(matcher stash.RefMatcher, ref string) bool {
+	switch matcher.Type.ID {
+	case stash.MatcherAnyRef:
+		return true
+	case stash.MatcherBranch:
+		return matcher.ID == ref
+	case stash.MatcherPattern:
+		matched, _ := path.Match(matcher.ID, strings.TrimPrefix(ref, "refs/heads/"))
+		return matched
+	}
+	return false
+}
+
+func nonNil(conditions []stash.DefaultReviewerCondition) []stash.DefaultReviewerCondition {
+	if conditions == nil {
+		return []stash.DefaultReviewerCondition{}
+	}
+	return conditions
+}
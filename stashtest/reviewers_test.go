@@ -0,0 +1,69 @@
+package stashtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestDefaultReviewers(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	branch := stash.RefMatcher{ID: "refs/heads/master", Type: stash.RefMatcherType{ID: stash.MatcherBranch}}
+	features := stash.RefMatcher{ID: "feature/*", Type: stash.RefMatcherType{ID: stash.MatcherPattern}}
+	anyRef := stash.RefMatcher{Type: stash.RefMatcherType{ID: stash.MatcherAnyRef}}
+	server.AddDefaultReviewers("PROJ", "widget", stash.DefaultReviewerCondition{
+		SourceRefMatcher: features,
+		TargetRefMatcher: branch,
+		Reviewers:        []stash.User{{Name: "bob"}, {Name: "admin"}},
+	})
+	server.AddDefaultReviewers("PROJ", "", stash.DefaultReviewerCondition{
+		SourceRefMatcher: anyRef,
+		TargetRefMatcher: anyRef,
+		Reviewers:        []stash.User{{Name: "carol"}, {Name: "bob"}},
+	})
+	server.AddDefaultReviewers("PROJ", "widget", stash.DefaultReviewerCondition{
+		SourceRefMatcher: anyRef,
+		TargetRefMatcher: stash.RefMatcher{ID: "refs/heads/release", Type: stash.RefMatcherType{ID: stash.MatcherBranch}},
+		Reviewers:        []stash.User{{Name: "dave"}},
+	})
+	client := server.Client()
+
+	conditions, err := client.GetDefaultReviewerConditions("PROJ", "widget")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(conditions) != 3 || conditions[2].Scope.Type != "PROJECT" {
+		t.Fatalf("Want 2 repository conditions and 1 project condition but got %+v\n", conditions)
+	}
+	if conditions, _ := client.GetProjectDefaultReviewerConditions("PROJ"); len(conditions) != 1 {
+		t.Fatalf("Want 1 project condition but got %+v\n", conditions)
+	}
+
+	from := stash.NewPullRequestRef("PROJ", "widget", "refs/heads/feature/readme")
+	to := stash.NewPullRequestRef("PROJ", "widget", "refs/heads/master")
+	users, err := client.GetDefaultReviewers(from, to)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	var names []string
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	if fmt.Sprint(names) != "[bob carol]" {
+		t.Fatalf("Want [bob carol] without the author but got %v\n", names)
+	}
+
+	pr, err := client.CreatePullRequestWithDefaultReviewers("Better readme", "", from, to, []string{"erin"})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	names = nil
+	for _, reviewer := range pr.Reviewers {
+		names = append(names, reviewer.User.Name)
+	}
+	if fmt.Sprint(names) != "[erin bob carol]" {
+		t.Fatalf("Want [erin bob carol] but got %v\n", names)
+	}
+}
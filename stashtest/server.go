@@ -0,0 +1,563 @@
+// Package stashtest provides an in-memory Bitbucket Server (Stash) for tests.
+//
+// Server keeps projects, repositories, branches, tags, commits, files, pull
+// requests, comments, branch restrictions, default reviewers, build statuses and
+// Code Insights reports in memory and serves them on the REST paths used by
+// package stash, so code written against stash.Stash can be tested end to end
+// without a live instance:
+//
+//	server := stashtest.NewServer()
+//	defer server.Close()
+//
+//	server.AddProject("PROJ")
+//	server.AddRepository("PROJ", "widget")
+//	server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+//
+//	client := server.Client()
+//	branches, err := client.GetBranches("PROJ", "widget")
+package stashtest
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xoom/stash"
+)
+
+type (
+	// Server is an in-memory Stash served over HTTP.  It is safe for concurrent use;
+	// requests are handled one at a time.
+	Server struct {
+		*httptest.Server
+
+		mu       sync.Mutex
+		routes   []route
+		projects map[string]*project
+		builds   map[string][]stash.BuildStatus // by commit ID
+
+		userName string
+		password string
+		token    string
+
+		nextRepositoryID  int
+		nextCommentID     int
+		nextTaskID        int
+		nextActivityID    int
+		nextRestrictionID int
+		nextConditionID   int
+		nextCommit        int
+	}
+
+	project struct {
+		key          string
+		repositories map[string]*repository
+		conditions   []stash.DefaultReviewerCondition
+	}
+
+	repository struct {
+		stash.Repository
+		branches       map[string]*stash.Branch
+		tags           map[string]*stash.Tag
+		commits        []stash.Commit
+		parents        map[string]string            // commit ID to the branch head it was added on
+		trees          map[string]map[string]string // files of commits no longer latest on their branch
+		files          map[string]map[string]string
+		pullRequests   []*pullRequest
+		pullRequestIDs int
+		restrictions   []stash.BranchRestriction
+		conditions     []stash.DefaultReviewerCondition
+		reports        map[string]*report // by commit ID and key
+	}
+
+	pullRequest struct {
+		stash.PullRequest
+		// from and to hold the repositories of FromRef and ToRef.
+		from, to   *repository
+		comments   []*comment
+		tasks      []*stash.Task
+		activities []*activity
+		vetoes     []stash.MergeVeto
+	}
+
+	// comment is stored flat; replies point at their parent.
+	comment struct {
+		stash.Comment
+		parent int
+	}
+
+	// activity refers to a live comment by ID so that it renders its current
+	// thread.
+	activity struct {
+		stash.Activity
+		comment int
+	}
+
+	// params holds the values of the {placeholders} of a matched route.
+	params map[string]string
+
+	route struct {
+		method   string
+		segments []string
+		handler  func(w http.ResponseWriter, r *http.Request, p params)
+	}
+)
+
+// NewServer starts a Server with no data that accepts any credentials.
+func NewServer() *Server {
+	s := &Server{projects: make(map[string]*project), builds: make(map[string][]stash.BuildStatus)}
+	s.registerRoutes()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Client returns a stash client for the server, authenticated with the
+// credentials the server requires, if any.
+func (s *Server) Client() stash.Stash {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	options := []stash.ClientOption{stash.WithHTTPClient(s.Server.Client())}
+	if s.token != "" {
+		options = append(options, stash.WithBearerToken(s.token))
+	} else {
+		options = append(options, stash.WithBasicAuth(s.userName, s.password))
+	}
+	client, err := stash.NewClientWithOptions(s.BaseURL(), options...)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// BaseURL returns the URL to hand to stash.NewClient.
+func (s *Server) BaseURL() *url.URL {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// RequireBasicAuth makes the server reject requests without these credentials.
+func (s *Server) RequireBasicAuth(userName, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userName, s.password, s.token = userName, password, ""
+}
+
+// RequireToken makes the server reject requests without this bearer token.
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userName, s.password, s.token = "", "", token
+}
+
+// AddProject creates a project.
+func (s *Server) AddProject(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addProject(key)
+}
+
+// AddRepository creates a git repository in an existing project.
+func (s *Server) AddRepository(projectKey, name string) stash.Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.projects[strings.ToUpper(projectKey)]
+	if !ok {
+		panic(fmt.Sprintf("stashtest: no project %s", projectKey))
+	}
+	return s.addRepository(p, name).Repository
+}
+
+// AddBranch creates a branch pointing at the head of from, or at no commit when
+// from is empty.
+func (s *Server) AddBranch(projectKey, slug, name, from string) stash.Branch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo := s.mustRepository(projectKey, slug)
+	branch := repo.addBranch(name)
+	if base, ok := repo.branches[from]; ok {
+		branch.LatestChangeSet = base.LatestChangeSet
+		repo.files[name] = copyFiles(repo.files[from])
+	}
+	return *branch
+}
+
+// AddTag creates a tag on commitID.
+func (s *Server) AddTag(projectKey, slug, name, commitID string) stash.Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo := s.mustRepository(projectKey, slug)
+	tag := &stash.Tag{ID: "refs/tags/" + name, DisplayID: name, Hash: commitID}
+	repo.tags[name] = tag
+	return *tag
+}
+
+// AddCommit appends commit to branch, creating the branch if needed.  Missing
+// IDs and timestamps are filled in, and the branch head becomes the first
+// parent, ahead of any parents commit already names.
+func (s *Server) AddCommit(projectKey, slug, branch string, commit stash.Commit) stash.Commit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addCommit(s.mustRepository(projectKey, slug), branch, commit)
+}
+
+func (s *Server) addCommit(repo *repository, branch string, commit stash.Commit) stash.Commit {
+	if commit.ID == "" {
+		s.nextCommit++
+		commit.ID = fmt.Sprintf("%x", sha1.Sum([]byte(strconv.Itoa(s.nextCommit))))
+	}
+	if commit.DisplayID == "" {
+		commit.DisplayID = shortID(commit.ID)
+	}
+	if commit.AuthorTimestamp == 0 {
+		commit.AuthorTimestamp = now()
+	}
+	if commit.Committer.Name == "" {
+		commit.Committer = commit.Author
+	}
+	if commit.CommitterTimestamp == 0 {
+		commit.CommitterTimestamp = commit.AuthorTimestamp
+	}
+
+	b, ok := repo.branches[branch]
+	if !ok {
+		b = repo.addBranch(branch)
+	}
+	previous := b.LatestChangeSet
+	if previous != "" {
+		repo.parents[commit.ID] = previous
+		if _, ok := repo.trees[previous]; !ok {
+			repo.trees[previous] = copyFiles(repo.files[branch])
+		}
+		commit.Parents = append([]stash.CommitParent{{ID: previous, DisplayID: shortID(previous)}}, commit.Parents...)
+	}
+	repo.commits = append(repo.commits, commit)
+	b.LatestChangeSet = commit.ID
+	s.rescope(repo, branch, previous, commit)
+	return commit
+}
+
+// shortID abbreviates a commit ID the way Stash displays it.
+func shortID(id string) string {
+	if len(id) < 7 {
+		return id
+	}
+	return id[:7]
+}
+
+// AddFile sets the content of path on branch, as part of its latest commit.
+func (s *Server) AddFile(projectKey, slug, branch, path, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo := s.mustRepository(projectKey, slug)
+	if _, ok := repo.branches[branch]; !ok {
+		repo.addBranch(branch)
+	}
+	repo.files[branch][path] = content
+}
+
+// PullRequest returns the current state of a pull request.
+func (s *Server) PullRequest(projectKey, slug string, id int) (stash.PullRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo := s.mustRepository(projectKey, slug)
+	pr := repo.pullRequest(id)
+	if pr == nil {
+		return stash.PullRequest{}, false
+	}
+	return pr.view(), true
+}
+
+// VetoMerge adds a merge check failure to a pull request, preventing its merge.
+func (s *Server) VetoMerge(projectKey, slug string, id int, veto stash.MergeVeto) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.mustRepository(projectKey, slug).pullRequest(id)
+	if pr == nil {
+		panic(fmt.Sprintf("stashtest: no pull request %s/%s#%d", projectKey, slug, id))
+	}
+	pr.vetoes = append(pr.vetoes, veto)
+}
+
+func (s *Server) addProject(key string) *project {
+	p := &project{key: key, repositories: make(map[string]*repository)}
+	s.projects[strings.ToUpper(key)] = p
+	return p
+}
+
+func (s *Server) addRepository(p *project, name string) *repository {
+	s.nextRepositoryID++
+	slug := strings.ToLower(strings.Replace(name, " ", "-", -1))
+	host := s.BaseURL().Host
+	repo := &repository{
+		Repository: stash.Repository{
+			ID:      s.nextRepositoryID,
+			Name:    name,
+			Slug:    slug,
+			Project: stash.Project{Key: p.key},
+			ScmID:   "git",
+			Links: stash.Links{Clones: []stash.Clone{
+				{HREF: fmt.Sprintf("ssh://git@%s/%s/%s.git", host, strings.ToLower(p.key), slug), Name: "ssh"},
+				{HREF: fmt.Sprintf("%s/scm/%s/%s.git", s.URL, strings.ToLower(p.key), slug), Name: "http"},
+			}},
+		},
+		branches: make(map[string]*stash.Branch),
+		tags:     make(map[string]*stash.Tag),
+		parents:  make(map[string]string),
+		trees:    make(map[string]map[string]string),
+		reports:  make(map[string]*report),
+		files:    make(map[string]map[string]string),
+	}
+	p.repositories[slug] = repo
+	return repo
+}
+
+func (s *Server) mustRepository(projectKey, slug string) *repository {
+	repo := s.lookup(projectKey, slug)
+	if repo == nil {
+		panic(fmt.Sprintf("stashtest: no repository %s/%s", projectKey, slug))
+	}
+	return repo
+}
+
+func (s *Server) lookup(projectKey, slug string) *repository {
+	p, ok := s.projects[strings.ToUpper(projectKey)]
+	if !ok {
+		return nil
+	}
+	return p.repositories[strings.ToLower(slug)]
+}
+
+// sortedRepositories returns every repository ordered by project and slug.
+func (s *Server) sortedRepositories() []*repository {
+	var repos []*repository
+	for _, p := range s.projects {
+		for _, repo := range p.repositories {
+			repos = append(repos, repo)
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i].Project.Key != repos[j].Project.Key {
+			return repos[i].Project.Key < repos[j].Project.Key
+		}
+		return repos[i].Slug < repos[j].Slug
+	})
+	return repos
+}
+
+func (repo *repository) addBranch(name string) *stash.Branch {
+	branch := &stash.Branch{ID: "refs/heads/" + name, DisplayID: name, IsDefault: len(repo.branches) == 0}
+	repo.branches[name] = branch
+	if repo.files[name] == nil {
+		repo.files[name] = make(map[string]string)
+	}
+	return branch
+}
+
+// nextPullRequestID returns a pull request ID never used in repo, even by
+// deleted pull requests.
+func (repo *repository) nextPullRequestID() int {
+	repo.pullRequestIDs++
+	return repo.pullRequestIDs
+}
+
+func (repo *repository) pullRequest(id int) *pullRequest {
+	for _, pr := range repo.pullRequests {
+		if pr.ID == id {
+			return pr
+		}
+	}
+	return nil
+}
+
+// ServeHTTP authenticates the request and dispatches it to the matching route.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "com.atlassian.bitbucket.AuthorisationException", "Authentication failed. Please check your credentials and try again.")
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	pathMatched := false
+	for _, rt := range s.routes {
+		p, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if rt.method == r.Method {
+			rt.handler(w, r, p)
+			return
+		}
+	}
+	if pathMatched {
+		writeError(w, http.StatusMethodNotAllowed, "", fmt.Sprintf("Method %s is not supported for %s", r.Method, r.URL.Path))
+		return
+	}
+	writeError(w, http.StatusNotFound, "", fmt.Sprintf("No resource at %s", r.URL.Path))
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token != "" {
+		return r.Header.Get("Authorization") == "Bearer "+s.token
+	}
+	if s.userName == "" && s.password == "" {
+		return true
+	}
+	userName, password, ok := r.BasicAuth()
+	return ok && userName == s.userName && password == s.password
+}
+
+// currentUser returns the user making the request.
+func (s *Server) currentUser(r *http.Request) stash.User {
+	name := "admin"
+	if userName, _, ok := r.BasicAuth(); ok && userName != "" {
+		name = userName
+	}
+	return newUser(name)
+}
+
+// newUser returns an active user called name.  Like Stash, the slug is the name
+// lowercased, with characters that aren't safe in a URL replaced by _.
+func newUser(name string) stash.User {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, strings.ToLower(name))
+	return stash.User{Name: name, Slug: slug, DisplayName: name, Active: true, Type: "NORMAL"}
+}
+
+// handle registers a handler for method and pattern, a path whose {name} segments
+// match anything and whose final {name...} segment matches the rest of the path.
+func (s *Server) handle(method, pattern string, handler func(w http.ResponseWriter, r *http.Request, p params)) {
+	s.routes = append(s.routes, route{
+		method:   method,
+		segments: strings.Split(strings.Trim(pattern, "/"), "/"),
+		handler:  handler,
+	})
+}
+
+func (rt route) match(segments []string) (params, bool) {
+	p := params{}
+	for i, pattern := range rt.segments {
+		if strings.HasPrefix(pattern, "{") && strings.HasSuffix(pattern, "...}") {
+			if i >= len(segments) {
+				return nil, false
+			}
+			p[strings.TrimSuffix(pattern[1:], "...}")] = strings.Join(segments[i:], "/")
+			return p, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if strings.HasPrefix(pattern, "{") && strings.HasSuffix(pattern, "}") {
+			p[pattern[1:len(pattern)-1]] = segments[i]
+			continue
+		}
+		if pattern != segments[i] {
+			return nil, false
+		}
+	}
+	return p, len(segments) == len(rt.segments)
+}
+
+// repository resolves the {project} and {repo} parameters, answering 404 itself
+// when there is no such repository.
+func (s *Server) repository(w http.ResponseWriter, p params) (*repository, bool) {
+	if _, ok := s.projects[strings.ToUpper(p["project"])]; !ok {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.project.NoSuchProjectException", fmt.Sprintf("Project %s does not exist.", p["project"]))
+		return nil, false
+	}
+	repo := s.lookup(p["project"], p["repo"])
+	if repo == nil {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.repository.NoSuchRepositoryException", fmt.Sprintf("Repository %s/%s does not exist.", p["project"], p["repo"]))
+		return nil, false
+	}
+	return repo, true
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with a Stash style error payload.
+func writeError(w http.ResponseWriter, statusCode int, exceptionName, message string) {
+	writeJSON(w, statusCode, map[string]interface{}{
+		"errors": []stash.ErrorDetail{{Message: message, ExceptionName: exceptionName}},
+	})
+}
+
+// writePage answers with the page of values selected by the start and limit
+// query parameters.
+func writePage(w http.ResponseWriter, r *http.Request, values []interface{}) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 25
+	}
+	if start < 0 || start > len(values) {
+		start = len(values)
+	}
+	end := start + limit
+	if end > len(values) {
+		end = len(values)
+	}
+
+	page := map[string]interface{}{
+		"start":      start,
+		"limit":      limit,
+		"size":       end - start,
+		"isLastPage": end == len(values),
+		"values":     values[start:end],
+	}
+	if end < len(values) {
+		page["nextPageStart"] = end
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "", fmt.Sprintf("Invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func copyFiles(files map[string]string) map[string]string {
+	c := make(map[string]string, len(files))
+	for path, content := range files {
+		c[path] = content
+	}
+	return c
+}
+
+func now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,239 @@
+package stashtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func newWidget() *Server {
+	server := NewServer()
+	server.AddProject("PROJ")
+	server.AddRepository("PROJ", "widget")
+	server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+	server.AddFile("PROJ", "widget", "master", "README.md", "widget")
+	server.AddBranch("PROJ", "widget", "feature/readme", "master")
+	server.AddFile("PROJ", "widget", "feature/readme", "README.md", "widget, improved")
+	server.AddFile("PROJ", "widget", "feature/readme", "docs/usage.md", "usage")
+	return server
+}
+
+func TestRepositories(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	if _, err := client.CreateRepository("PROJ", "gadget"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.CreateRepository("PROJ", "gadget"); !stash.IsRepositoryExists(err) {
+		t.Fatalf("Want repository exists error but got %v\n", err)
+	}
+	if _, err := client.GetRepository("PROJ", "missing"); !stash.IsRepositoryNotFound(err) {
+		t.Fatalf("Want repository not found error but got %v\n", err)
+	}
+
+	repos, err := client.GetRepositories()
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("Want 2 repositories but got %d\n", len(repos))
+	}
+
+	branches, err := client.GetBranches("PROJ", "widget")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, ok := branches["feature/readme"]; !ok || len(branches) != 2 {
+		t.Fatalf("Want master and feature/readme but got %v\n", branches)
+	}
+	if err := client.DeleteBranch("PROJ", "widget", "feature/readme"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if err := client.DeleteBranch("PROJ", "widget", "feature/readme"); err == nil {
+		t.Fatalf("Want error deleting a missing branch but got none\n")
+	}
+}
+
+func TestCommitsAndFiles(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	first := server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+	second := server.AddCommit("PROJ", "widget", "master", stash.Commit{})
+	server.AddTag("PROJ", "widget", "v1.0", second.ID)
+
+	commits, err := client.GetCommits("PROJ", "widget", first.ID, "master")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(commits.Commits) != 1 || commits.Commits[0].ID != second.ID {
+		t.Fatalf("Want only %s but got %+v\n", second.ID, commits.Commits)
+	}
+	commit, err := client.GetCommit("PROJ", "widget", second.DisplayID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if commit.ID != second.ID {
+		t.Fatalf("Want %s but got %s\n", second.ID, commit.ID)
+	}
+
+	tags, err := client.GetTags("PROJ", "widget")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if tags["v1.0"].Hash != second.ID {
+		t.Fatalf("Want v1.0 on %s but got %+v\n", second.ID, tags)
+	}
+
+	data, err := client.GetRawFile("PROJ", "widget", "docs/usage.md", "feature/readme")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if string(data) != "usage" {
+		t.Fatalf("Want usage but got %s\n", data)
+	}
+	if _, err := client.GetRawFile("PROJ", "widget", "docs/usage.md", "master"); !stash.IsNotFound(err) {
+		t.Fatalf("Want not found error but got %v\n", err)
+	}
+}
+
+func TestShortCommitIDs(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+
+	first := server.AddCommit("PROJ", "widget", "hotfix", stash.Commit{ID: "abc"})
+	second := server.AddCommit("PROJ", "widget", "hotfix", stash.Commit{ID: "def"})
+	if first.DisplayID != "abc" || second.Parents[0].DisplayID != "abc" {
+		t.Fatalf("Want abc displayed in full but got %+v\n", second)
+	}
+}
+
+func TestPullRequests(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	pr, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", []string{"bob"})
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if pr.ID != 1 || pr.State != "OPEN" || pr.Reviewers[0].User.Name != "bob" {
+		t.Fatalf("Want open pull request 1 reviewed by bob but got %+v\n", pr)
+	}
+	if _, err := client.CreatePullRequest("PROJ", "widget", "Again", "", "feature/readme", "master", nil); !stash.IsConflict(err) {
+		t.Fatalf("Want conflict for a duplicate pull request but got %v\n", err)
+	}
+
+	changes, err := client.GetPullRequestChangedPaths("PROJ", "widget", pr.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if fmt.Sprint(changes) != "[README.md docs/usage.md]" {
+		t.Fatalf("Want README.md and docs/usage.md but got %v\n", changes)
+	}
+	details, err := client.GetPullRequestChanges("PROJ", "widget", pr.ID)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if details[0].Type != stash.ChangeModify || details[1].Type != stash.ChangeAdd || details[1].Path.Parent != "docs" {
+		t.Fatalf("Want README.md modified and docs/usage.md added but got %+v\n", details)
+	}
+
+	updated, err := client.UpdatePullRequest("PROJ", "widget", "1", pr.Version, "Much better readme", "", "", nil)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if updated.Title != "Much better readme" || updated.Version != pr.Version+1 {
+		t.Fatalf("Want new title and version but got %+v\n", updated)
+	}
+	if _, err := client.UpdatePullRequest("PROJ", "widget", "1", pr.Version, "Stale", "", "", nil); !stash.IsStaleVersion(err) {
+		t.Fatalf("Want stale version error but got %v\n", err)
+	}
+
+	if err := client.DeclinePullRequest("PROJ", "widget", pr.ID, updated.Version); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	declined, _ := server.PullRequest("PROJ", "widget", pr.ID)
+	if declined.State != "DECLINED" {
+		t.Fatalf("Want DECLINED but got %s\n", declined.State)
+	}
+
+	open, err := client.GetPullRequests("PROJ", "widget", "OPEN")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(open) != 0 {
+		t.Fatalf("Want no open pull requests but got %d\n", len(open))
+	}
+	if _, err := client.GetPullRequest("PROJ", "widget", "2"); !stash.IsNotFound(err) {
+		t.Fatalf("Want not found error but got %v\n", err)
+	}
+}
+
+func TestComments(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	if _, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	comment, err := client.CreateComment("PROJ", "widget", "1", "build passing")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if comment.ID != 1 || comment.Author.Name != "admin" {
+		t.Fatalf("Want comment 1 by admin but got %+v\n", comment)
+	}
+	comments, err := client.GetComments("PROJ", "widget", "1", "")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(comments) != 1 || comments[0].Text != "build passing" {
+		t.Fatalf("Want the build passing comment but got %+v\n", comments)
+	}
+}
+
+func TestBranchRestrictions(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	restriction, err := client.CreateBranchRestriction("PROJ", "widget", "master", "bob")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	restrictions, err := client.GetBranchRestrictions("PROJ", "widget")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(restrictions.BranchRestriction) != 1 {
+		t.Fatalf("Want 1 restriction but got %d\n", len(restrictions.BranchRestriction))
+	}
+	if err := client.DeleteBranchRestriction("PROJ", "widget", restriction.Id); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	server.RequireBasicAuth("bob", "secret")
+
+	if _, err := server.Client().GetRepository("PROJ", "widget"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	client := stash.NewClient("bob", "wrong", server.BaseURL())
+	if _, err := client.GetRepository("PROJ", "widget"); !stash.IsUnauthorized(err) {
+		t.Fatalf("Want unauthorized error but got %v\n", err)
+	}
+
+	server.RequireToken("t0ken")
+	if _, err := server.Client().GetRepository("PROJ", "widget"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+}
@@ -0,0 +1,107 @@
+package stashtest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/xoom/stash"
+)
+
+type taskResource struct {
+	Text   string           `json:"text"`
+	State  string           `json:"state"`
+	Anchor stash.TaskAnchor `json:"anchor"`
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request, p params) {
+	_, pr, ok := s.pullRequest(w, p)
+	if !ok {
+		return
+	}
+	var values []interface{}
+	for _, task := range pr.tasks {
+		values = append(values, *task)
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request, p params) {
+	var body taskResource
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.Text == "" || body.Anchor.Type != "COMMENT" {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", "A task needs a text and a comment anchor.")
+		return
+	}
+	pr := s.commentPullRequest(body.Anchor.ID)
+	if pr == nil {
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.comment.NoSuchCommentException", fmt.Sprintf("No comment exists with ID %d.", body.Anchor.ID))
+		return
+	}
+
+	s.nextTaskID++
+	task := &stash.Task{
+		ID:          s.nextTaskID,
+		Text:        body.Text,
+		State:       stash.TaskOpen,
+		Author:      s.currentUser(r),
+		CreatedDate: now(),
+		Anchor:      stash.TaskAnchor{ID: body.Anchor.ID, Type: "COMMENT"},
+	}
+	pr.tasks = append(pr.tasks, task)
+	writeJSON(w, http.StatusCreated, *task)
+}
+
+// updateTask changes the state or the text of a task.
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request, p params) {
+	var body taskResource
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	id, _ := strconv.Atoi(p["task"])
+	task := s.task(id)
+	if task == nil {
+		writeError(w, http.StatusNotFound, "com.atlassian.bitbucket.task.NoSuchTaskException", fmt.Sprintf("No task exists with ID %s.", p["task"]))
+		return
+	}
+
+	switch body.State {
+	case "":
+	case stash.TaskOpen, stash.TaskResolved:
+		task.State = body.State
+	default:
+		writeError(w, http.StatusBadRequest, "com.atlassian.bitbucket.validation.ArgumentValidationException", fmt.Sprintf("Invalid task state %q.", body.State))
+		return
+	}
+	if body.Text != "" {
+		task.Text = body.Text
+	}
+	writeJSON(w, http.StatusOK, *task)
+}
+
+// commentPullRequest returns the pull request holding the comment id.
+func (s *Server) commentPullRequest(id int) *pullRequest {
+	for _, repo := range s.sortedRepositories() {
+		for _, pr := range repo.pullRequests {
+			if pr.comment(id) != nil {
+				return pr
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) task(id int) *stash.Task {
+	for _, repo := range s.sortedRepositories() {
+		for _, pr := range repo.pullRequests {
+			for _, task := range pr.tasks {
+				if task.ID == id {
+					return task
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package stashtest
+
+import (
+	"testing"
+
+	"github.com/xoom/stash"
+)
+
+func TestTasks(t *testing.T) {
+	server := newWidget()
+	defer server.Close()
+	client := server.Client()
+
+	if _, err := client.CreatePullRequest("PROJ", "widget", "Better readme", "", "feature/readme", "master", nil); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	comment, err := client.CreateComment("PROJ", "widget", "1", "a few things")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	first, err := client.CreateTask(comment.ID, "fix the typo")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.CreateTask(comment.ID, "add an example"); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if _, err := client.ResolveTask(first.ID); err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+
+	tasks, err := client.GetTasks("PROJ", "widget", 1)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(tasks) != 2 || tasks[0].State != stash.TaskResolved || tasks[1].State != stash.TaskOpen {
+		t.Fatalf("Want a resolved and an open task but got %+v\n", tasks)
+	}
+	pr, err := client.GetPullRequest("PROJ", "widget", "1")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if pr.Properties.OpenTaskCount != 1 || pr.Properties.ResolvedTaskCount != 1 {
+		t.Fatalf("Want 1 open and 1 resolved task but got %+v\n", pr.Properties)
+	}
+	if _, err := client.CreateTask(99, "orphan"); err == nil {
+		t.Fatalf("Want error for a task on a missing comment but got none\n")
+	}
+}
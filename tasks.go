@@ -0,0 +1,140 @@
+package stash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Task states.
+const (
+	TaskOpen     = "OPEN"
+	TaskResolved = "RESOLVED"
+)
+
+type (
+	// Task is a to-do item attached to a pull request comment.
+	Task struct {
+		ID          int        `json:"id"`
+		Text        string     `json:"text"`
+		State       string     `json:"state"`
+		Author      User       `json:"author"`
+		CreatedDate int64      `json:"createdDate"`
+		Anchor      TaskAnchor `json:"anchor"`
+	}
+
+	// TaskAnchor is the comment a task belongs to.
+	TaskAnchor struct {
+		ID   int    `json:"id"`
+		Type string `json:"type"`
+	}
+
+	// PullRequestProperties holds the comment and task counts of a pull request.
+	PullRequestProperties struct {
+		CommentCount      int `json:"commentCount"`
+		OpenTaskCount     int `json:"openTaskCount"`
+		ResolvedTaskCount int `json:"resolvedTaskCount"`
+	}
+
+	taskResource struct {
+		ID     int         `json:"id,omitempty"`
+		Text   string      `json:"text,omitempty"`
+		State  string      `json:"state,omitempty"`
+		Anchor *TaskAnchor `json:"anchor,omitempty"`
+	}
+)
+
+// CreateTask adds a task to the pull request comment commentID.
+func (client Client) CreateTask(commentID int, text string) (Task, error) {
+	return client.CreateTaskContext(context.Background(), commentID, text)
+}
+
+// CreateTaskContext is like CreateTask but uses ctx for the request.
+func (client Client) CreateTaskContext(ctx context.Context, commentID int, text string) (Task, error) {
+	return client.sendTask(ctx, "POST", fmt.Sprintf("%s/rest/api/1.0/tasks", client.baseURL.String()), http.StatusCreated,
+		taskResource{Text: text, Anchor: &TaskAnchor{ID: commentID, Type: "COMMENT"}})
+}
+
+// GetTasks returns the open and resolved tasks of a pull request.
+func (client Client) GetTasks(projectKey, repositorySlug string, pullRequestID int) ([]Task, error) {
+	return client.GetTasksContext(context.Background(), projectKey, repositorySlug, pullRequestID)
+}
+
+// GetTasksContext is like GetTasks but uses ctx for every page it requests.
+func (client Client) GetTasksContext(ctx context.Context, projectKey, repositorySlug string, pullRequestID int) ([]Task, error) {
+	var tasks []Task
+	it := client.Paginate(ctx, fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/tasks", projectKey, repositorySlug, pullRequestID), nil, PageOptions{})
+	for it.Next() {
+		var task Task
+		if err := it.Decode(&task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ResolveTask marks a task as done.
+func (client Client) ResolveTask(taskID int) (Task, error) {
+	return client.ResolveTaskContext(context.Background(), taskID)
+}
+
+// ResolveTaskContext is like ResolveTask but uses ctx for the request.
+func (client Client) ResolveTaskContext(ctx context.Context, taskID int) (Task, error) {
+	return client.sendTask(ctx, "PUT", fmt.Sprintf("%s/rest/api/1.0/tasks/%d", client.baseURL.String(), taskID), http.StatusOK,
+		taskResource{ID: taskID, State: TaskResolved})
+}
+
+// ReopenTask marks a resolved task as open again.
+func (client Client) ReopenTask(taskID int) (Task, error) {
+	return client.ReopenTaskContext(context.Background(), taskID)
+}
+
+// ReopenTaskContext is like ReopenTask but uses ctx for the request.
+func (client Client) ReopenTaskContext(ctx context.Context, taskID int) (Task, error) {
+	return client.sendTask(ctx, "PUT", fmt.Sprintf("%s/rest/api/1.0/tasks/%d", client.baseURL.String(), taskID), http.StatusOK,
+		taskResource{ID: taskID, State: TaskOpen})
+}
+
+func (client Client) sendTask(ctx context.Context, method, url string, wantCode int, resource taskResource) (Task, error) {
+	reqBody, err := json.Marshal(resource)
+	if err != nil {
+		return Task{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return Task{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-type", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if responseCode != wantCode {
+		reason := "unhandled reason"
+		switch {
+		case responseCode == http.StatusBadRequest:
+			reason = "The task was not saved due to a validation error."
+		case responseCode == http.StatusUnauthorized:
+			reason = "Unauthorized"
+		case responseCode == http.StatusForbidden:
+			reason = "The currently authenticated user may not change this task."
+		case responseCode == http.StatusNotFound:
+			reason = "Not found"
+		}
+		return Task{}, newAPIError(req, responseCode, data, reason)
+	}
+
+	var task Task
+	err = json.Unmarshal(data, &task)
+	return task, err
+}
@@ -0,0 +1,94 @@
+package stash
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCreateTask(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/rest/api/1.0/tasks" {
+			t.Errorf("Want POST /rest/api/1.0/tasks but got %s %s\n", r.Method, r.URL.Path)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if want := `{"text":"add a test","anchor":{"id":3,"type":"COMMENT"}}`; string(body) != want {
+			t.Errorf("Want %s but got %s\n", want, body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 9, "text": "add a test", "state": "OPEN", "anchor": {"id": 3, "type": "COMMENT"}}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	task, err := stashClient.CreateTask(3, "add a test")
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if task.ID != 9 || task.State != TaskOpen || task.Anchor.ID != 3 {
+		t.Fatalf("Want open task 9 on comment 3 but got %+v\n", task)
+	}
+}
+
+func TestResolveAndReopenTask(t *testing.T) {
+	var tests = []struct {
+		change func(Stash) (Task, error)
+		state  string
+	}{
+		{
+			change: func(stashClient Stash) (Task, error) { return stashClient.ResolveTask(9) },
+			state:  TaskResolved,
+		},
+		{
+			change: func(stashClient Stash) (Task, error) { return stashClient.ReopenTask(9) },
+			state:  TaskOpen,
+		},
+	}
+
+	for testNumber, test := range tests {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "PUT" || r.URL.Path != "/rest/api/1.0/tasks/9" {
+				t.Errorf("Test %d: want PUT /rest/api/1.0/tasks/9 but got %s %s\n", testNumber, r.Method, r.URL.Path)
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			if want := fmt.Sprintf(`{"id":9,"state":"%s"}`, test.state); string(body) != want {
+				t.Errorf("Test %d: want %s but got %s\n", testNumber, want, body)
+			}
+			fmt.Fprintf(w, `{"id": 9, "state": "%s"}`, test.state)
+		}))
+		defer testServer.Close()
+
+		url, _ := url.Parse(testServer.URL)
+		task, err := test.change(NewClient("u", "p", url))
+		if err != nil {
+			t.Fatalf("Test %d: not expecting error: %v\n", testNumber, err)
+		}
+		if task.State != test.state {
+			t.Fatalf("Test %d: want %s but got %s\n", testNumber, test.state, task.State)
+		}
+	}
+}
+
+func TestGetTasks(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/slug/pull-requests/7/tasks" {
+			t.Errorf("Want tasks path but got %s\n", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"isLastPage": true, "values": [{"id": 9, "state": "OPEN"}, {"id": 10, "state": "RESOLVED"}]}`)
+	}))
+	defer testServer.Close()
+
+	url, _ := url.Parse(testServer.URL)
+	stashClient := NewClient("u", "p", url)
+	tasks, err := stashClient.GetTasks("PROJ", "slug", 7)
+	if err != nil {
+		t.Fatalf("Not expecting error: %v\n", err)
+	}
+	if len(tasks) != 2 || tasks[1].State != TaskResolved {
+		t.Fatalf("Want an open and a resolved task but got %+v\n", tasks)
+	}
+}